@@ -0,0 +1,378 @@
+// Package analyzer provides an analysis.Analyzer which checks the annotations of blueprinter,
+// so that they are checked by a standalone checker, `go vet -vettool` or golangci-lint as well as by `blueprinter generate`.
+package analyzer
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"github.com/yuemori/blueprinter/internal/parser"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+const doc = `check the annotations of blueprinter
+
+The blueprinter analyzer reports:
+  - unknown or malformed directives like provider:exlude
+  - directives on declarations which blueprinter never reads, such as methods and unexported functions
+  - provider:exclude on declarations which are never considered as constructors, implementations or interfaces
+  - provider:resolve and provider:case targets which do not exist or do not implement the interface
+  - blueprint.Bind whose constructor is not a function returning an implementation of the interface,
+    or which is not declared as a package level variable
+  - constructors marked as provider:must_resolve, or declared in a package marked as provider:strict,
+    which cannot be resolved in the containers
+
+The checks referring to other packages load the module, and are skipped unless LoadModule is enabled.`
+
+// Analyzer checks the annotations of blueprinter.
+var Analyzer = &analysis.Analyzer{
+	Name: "blueprinter",
+	Doc:  doc,
+	Run:  run,
+}
+
+var (
+	annotationPrefix  string
+	legacyAnnotations bool
+	containers        string
+)
+
+func init() {
+	addFlags(&Analyzer.Flags)
+}
+
+// addFlags adds the flags shared by the analyzers to 'fs'.
+func addFlags(fs *flag.FlagSet) {
+	fs.StringVar(&annotationPrefix, "annotation-prefix", parser.LegacyPrefix, "Prefix of the annotations like provider:resolve, e.g. blueprinter:")
+	fs.BoolVar(&legacyAnnotations, "legacy-annotations", true, "Accept the annotations prefixed by "+parser.LegacyPrefix+" as well as -annotation-prefix")
+	fs.StringVar(&containers, "containers", "", "Comma separated container structs like path/to/package.Container. If empty, the structs marked as blueprinter:container are used")
+}
+
+// namespace returns the namespace given by the flags.
+func namespace() (parser.Namespace, error) {
+	ns := parser.Namespace{Prefix: annotationPrefix, Legacy: legacyAnnotations}
+	return ns, ns.Validate()
+}
+
+// A checker checks the annotations of a package.
+type checker struct {
+	pass *analysis.Pass
+	ns   parser.Namespace
+	// program is the module of the package, which is loaded on demand.
+	program *program
+	loaded  bool
+	// strict is true if the package is marked as `provider:strict` in its package comment.
+	strict bool
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	ns, err := namespace()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &checker{pass: pass, ns: ns}
+	for _, file := range pass.Files {
+		c.checkPackageDoc(file.Doc)
+	}
+	for _, file := range pass.Files {
+		c.checkBinds(file)
+	}
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				c.checkDecl(d.Doc, d.Name, d.Recv != nil)
+			case *ast.GenDecl:
+				if d.Tok != token.TYPE {
+					c.checkUnread(d.Doc, fmt.Sprintf("the %s declaration", d.Tok))
+					continue
+				}
+				for _, spec := range d.Specs {
+					if t, ok := spec.(*ast.TypeSpec); ok {
+						c.checkDecl(parser.DocOf(d, t), t.Name, false)
+					}
+				}
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// checkDecl checks the directives in the doc comment of the function or the type declared as 'ident'.
+func (c *checker) checkDecl(doc *ast.CommentGroup, ident *ast.Ident, method bool) {
+	obj := c.pass.TypesInfo.Defs[ident]
+	if obj == nil {
+		return
+	}
+	if doc == nil {
+		// Constructors without comments must be resolved as well in the strict package.
+		if _, ok := obj.(*types.Func); ok && c.strict && !method && obj.Exported() {
+			c.checkResolved(ident, "declared in the package marked as "+c.ns.Format(parser.DirectiveStrict))
+		}
+		return
+	}
+
+	o, errs := parser.NewAnnotatedObject(c.pass.Fset, obj, doc, c.ns)
+	c.reportErrors(doc, errs)
+	for _, d := range o.Directives() {
+		if d.Name == parser.DirectiveStrict {
+			c.pass.Reportf(posOf(c.pass.Fset, doc.Pos(), d.Pos), "%s on %s is never read by blueprinter, write it in the package comment", d.Written(), ident.Name)
+		}
+	}
+
+	switch {
+	case method:
+		c.reportUnread(doc, o.Directives(), "the method "+ident.Name)
+		return
+	case !obj.Exported():
+		// The container struct given by its name may be unexported.
+		provider := make([]*parser.Directive, 0)
+		for _, d := range o.Directives() {
+			if d.IsProvider() {
+				provider = append(provider, d)
+			}
+		}
+		c.reportUnread(doc, provider, "the unexported "+ident.Name)
+		return
+	}
+
+	if o.IsExcluded() && !o.IsConsidered() {
+		for _, d := range o.Directives() {
+			if d.Name == parser.DirectiveExclude {
+				c.pass.Reportf(posOf(c.pass.Fset, doc.Pos(), d.Pos), "%s has no effect, since %s is %s", d.Written(), ident.Name, c.neverConsidered(o))
+			}
+		}
+	}
+
+	if iface, ok := o.Interface(); ok && !iface.IsGeneric() {
+		for _, d := range o.Directives() {
+			switch d.Name {
+			case parser.DirectiveResolve:
+				c.checkTarget(doc, d, ident, d.Args)
+			case parser.DirectiveCase:
+				c.checkTarget(doc, d, ident, d.Args[1:])
+			}
+		}
+	}
+
+	if fn, ok := o.Func(); ok {
+		switch {
+		case fn.MustBeResolved():
+			c.checkResolved(ident, "marked as "+c.ns.Format(parser.DirectiveMustResolve))
+		case c.strict:
+			c.checkResolved(ident, "declared in the package marked as "+c.ns.Format(parser.DirectiveStrict))
+		}
+	}
+}
+
+// checkResolved reports the containers which cannot resolve the constructor 'ident', which must be resolved since it is 'why'.
+func (c *checker) checkResolved(ident *ast.Ident, why string) {
+	p := c.load()
+	if p == nil {
+		return
+	}
+	for _, err := range p.failures[c.pass.Pkg.Path()+"."+ident.Name] {
+		c.pass.Reportf(ident.Pos(), "%s is %s, but cannot be resolved %s", ident.Name, why, strings.TrimSpace(err.Error()))
+	}
+}
+
+// checkPackageDoc checks the directives in the package comment, where only `provider:strict` is read.
+func (c *checker) checkPackageDoc(doc *ast.CommentGroup) {
+	directives, errs := parser.ParseDirectives(c.pass.Fset, doc, c.ns)
+	c.reportErrors(doc, errs)
+	unread := make([]*parser.Directive, 0)
+	for _, d := range directives {
+		if d.Name == parser.DirectiveStrict {
+			c.strict = true
+			continue
+		}
+		unread = append(unread, d)
+	}
+	c.reportUnread(doc, unread, "the package clause")
+}
+
+// checkUnread reports the directives in the doc comment of the declaration which is never read.
+func (c *checker) checkUnread(doc *ast.CommentGroup, what string) {
+	directives, errs := parser.ParseDirectives(c.pass.Fset, doc, c.ns)
+	c.reportErrors(doc, errs)
+	c.reportUnread(doc, directives, what)
+}
+
+func (c *checker) reportUnread(doc *ast.CommentGroup, directives []*parser.Directive, what string) {
+	for _, d := range directives {
+		c.pass.Reportf(posOf(c.pass.Fset, doc.Pos(), d.Pos), "%s on %s is never read by blueprinter", d.Written(), what)
+	}
+}
+
+func (c *checker) reportErrors(doc *ast.CommentGroup, errs []error) {
+	for _, err := range errs {
+		if derr, ok := err.(*parser.DirectiveError); ok {
+			c.pass.Reportf(posOf(c.pass.Fset, doc.Pos(), derr.Pos), "%s", derr.Err)
+			continue
+		}
+		c.pass.Reportf(doc.Pos(), "%s", err)
+	}
+}
+
+// checkTarget checks the target of `provider:resolve` or `provider:case` given as '[path/to/package] FuncName'.
+func (c *checker) checkTarget(doc *ast.CommentGroup, d *parser.Directive, ident *ast.Ident, args []string) {
+	pkg, name := c.pass.Pkg.Path(), args[len(args)-1]
+	if len(args) > 1 {
+		pkg = args[0]
+	}
+	pos := posOf(c.pass.Fset, doc.Pos(), d.Pos)
+
+	// Targets in the same package are checked without loading the module.
+	if pkg == c.pass.Pkg.Path() {
+		target := c.pass.Pkg.Scope().Lookup(name)
+		if target == nil {
+			c.pass.Reportf(pos, "%s: %s is not found in %s", d.Written(), name, pkg)
+			return
+		}
+		if err := implements(target.Type(), c.pass.TypesInfo.Defs[ident].Type()); err != nil {
+			c.pass.Reportf(pos, "%s: %s.%s %s", d.Written(), pkg, name, err)
+		}
+		return
+	}
+
+	p := c.load()
+	if p == nil {
+		return
+	}
+	iface, ok := p.cache.Get(c.pass.Pkg.Path(), ident.Name)
+	if !ok {
+		return
+	}
+	target, ok := p.cache.Get(pkg, name)
+	if !ok {
+		c.pass.Reportf(pos, "%s: %s.%s is not found in the scanned packages", d.Written(), pkg, name)
+		return
+	}
+	if err := implements(target.Type(), iface.Type()); err != nil {
+		c.pass.Reportf(pos, "%s: %s.%s %s", d.Written(), pkg, name, err)
+	}
+}
+
+// checkBinds checks the calls of `blueprint.Bind` in 'file', which are read only as the values of package level variables.
+func (c *checker) checkBinds(file *ast.File) {
+	read := make(map[*ast.CallExpr]bool)
+	for _, decl := range file.Decls {
+		g, ok := decl.(*ast.GenDecl)
+		if !ok || g.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range g.Specs {
+			vs, ok := spec.(*ast.ValueSpec)
+			if !ok {
+				continue
+			}
+			for _, value := range vs.Values {
+				if call, ok := astutil.Unparen(value).(*ast.CallExpr); ok && parser.IsMarker(c.pass.TypesInfo, call.Fun, "Bind") {
+					read[call] = true
+					c.checkBind(call)
+				}
+			}
+		}
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if ok && !read[call] && parser.IsMarker(c.pass.TypesInfo, call.Fun, "Bind") {
+			c.pass.Reportf(call.Pos(), "blueprint.Bind is never read by blueprinter, declare it like `var _ = blueprint.Bind[Iface](NewImpl)`")
+		}
+		return true
+	})
+}
+
+// checkBind checks that the constructor given to `blueprint.Bind[T]` by 'call' returns an implementation of T,
+// which is not checked by the compiler since the constructor is given as interface{}.
+func (c *checker) checkBind(call *ast.CallExpr) {
+	target, fn, err := parser.ReadBind(c.pass.TypesInfo, call)
+	if err != nil {
+		c.pass.Reportf(call.Pos(), "%s", err)
+		return
+	}
+	if named, ok := target.(*types.Named); !ok || !types.IsInterface(named) {
+		c.pass.Reportf(call.Pos(), "blueprint.Bind: %s is not a named interface", parser.TypeNamePrefixedByImportPath(target))
+		return
+	}
+
+	arg := call.Args[0]
+	sig := fn.Type().(*types.Signature)
+	switch {
+	case sig.Recv() != nil:
+		c.pass.Reportf(arg.Pos(), "blueprint.Bind: %s is a method, but the constructor must be a function", fn.Name())
+	case !fn.Exported():
+		c.pass.Reportf(arg.Pos(), "blueprint.Bind: %s is unexported and cannot be called from the container", fn.Name())
+	case sig.TypeParams().Len() > 0:
+		c.pass.Reportf(arg.Pos(), "blueprint.Bind: %s is generic, but the constructor must be a non-generic function", fn.Name())
+	default:
+		if err := implements(sig, target); err != nil {
+			c.pass.Reportf(arg.Pos(), "blueprint.Bind: %s %s", fn.Name(), err)
+		}
+	}
+}
+
+// load returns the module of the package, or nil if it cannot be loaded.
+func (c *checker) load() *program {
+	if c.loaded {
+		return c.program
+	}
+	c.loaded = true
+	c.program = programOf(c.pass, c.ns)
+	return c.program
+}
+
+// implements returns an error if 'target' is neither a function returning an implementation of 'iface'
+// nor a struct implementing 'iface'.
+func implements(target, iface types.Type) error {
+	switch t := target.(type) {
+	case *types.Signature:
+		if t.Results().Len() != 1 {
+			return fmt.Errorf("does not return a single value")
+		}
+		result := t.Results().At(0).Type()
+		if !parser.AssignableTo(result, iface) {
+			return fmt.Errorf("returns %s, which does not implement %s", parser.TypeNamePrefixedByImportPath(result), parser.TypeNamePrefixedByImportPath(iface))
+		}
+		return nil
+	case *types.Named:
+		if _, ok := t.Underlying().(*types.Struct); !ok {
+			break
+		}
+		if !parser.AssignableTo(types.NewPointer(t), iface) {
+			return fmt.Errorf("does not implement %s", parser.TypeNamePrefixedByImportPath(iface))
+		}
+		return nil
+	}
+	return fmt.Errorf("is neither a function nor a struct")
+}
+
+// neverConsidered describes why 'o' is never considered by the resolver.
+func (c *checker) neverConsidered(o *parser.Object) string {
+	if _, ok := o.Func(); ok {
+		return "not a constructor, which returns a single value and has params or " + c.ns.Format(parser.DirectiveInclude)
+	}
+	return "neither a struct nor an interface"
+}
+
+// posOf returns the token.Pos of 'position' in the file containing 'anchor'.
+// A position without column is the start of the line.
+func posOf(fset *token.FileSet, anchor token.Pos, position token.Position) token.Pos {
+	f := fset.File(anchor)
+	if f == nil || position.Line < 1 || position.Line > f.LineCount() {
+		return anchor
+	}
+	pos := f.LineStart(position.Line)
+	if position.Column > 0 {
+		pos += token.Pos(position.Column - 1)
+	}
+	return pos
+}
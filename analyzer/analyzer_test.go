@@ -0,0 +1,16 @@
+package analyzer_test
+
+import (
+	"testing"
+
+	"github.com/yuemori/blueprinter/analyzer"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), analyzer.Analyzer, "./annotations", "./service")
+}
+
+func TestDirectCallAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), analyzer.DirectCallAnalyzer, "./handler", "./container")
+}
@@ -0,0 +1,30 @@
+// blueprinter-vet checks the annotations of blueprinter. Run standalone to check all of them:
+//
+//	go install github.com/yuemori/blueprinter/analyzer/cmd/blueprinter-vet
+//	blueprinter-vet ./...
+//
+// It also runs as a vet tool, which checks only the annotations within each package,
+// since go vet runs it for each package and the module cannot be loaded for every package:
+//
+//	go vet -vettool=$(which blueprinter-vet) ./...
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/yuemori/blueprinter/analyzer"
+	"golang.org/x/tools/go/analysis/multichecker"
+)
+
+func main() {
+	if vetMode(os.Args[1:]) {
+		analyzer.LoadModule = false
+	}
+	multichecker.Main(analyzer.Analyzer, analyzer.DirectCallAnalyzer)
+}
+
+// vetMode returns true if the tool is run by go vet, which passes the config file of a package as the last argument.
+func vetMode(args []string) bool {
+	return len(args) > 0 && strings.HasSuffix(args[len(args)-1], ".cfg")
+}
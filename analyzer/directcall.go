@@ -0,0 +1,105 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"github.com/yuemori/blueprinter/internal/parser"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const directCallDoc = `report direct calls of the constructors managed by the containers
+
+Calling a constructor like repository.NewUserRepository(db, logger) out of the container defeats DI.
+The blueprinterdirectcall analyzer reports the calls of the constructors called by the resolved containers,
+except in the container packages, the test files and the packages allowed by -allow-packages.`
+
+// DirectCallAnalyzer reports the calls of the constructors managed by the containers, which bypass the containers.
+var DirectCallAnalyzer = &analysis.Analyzer{
+	Name: "blueprinterdirectcall",
+	Doc:  directCallDoc,
+	Run:  runDirectCall,
+}
+
+var allowPackages, allowConstructors string
+
+func init() {
+	addFlags(&DirectCallAnalyzer.Flags)
+	DirectCallAnalyzer.Flags.StringVar(&allowPackages, "allow-packages", "", "Comma separated import path patterns of packages allowed to call the constructors, like github.com/owner/repo/cmd/...")
+	DirectCallAnalyzer.Flags.StringVar(&allowConstructors, "allow-constructors", "", "Comma separated constructors allowed to be called anywhere, like path/to/package.NewFoo")
+}
+
+func runDirectCall(pass *analysis.Pass) (interface{}, error) {
+	ns, err := namespace()
+	if err != nil {
+		return nil, err
+	}
+	if allowPackages != "" && parser.MatchesImportPath(pass.Pkg.Path(), strings.Split(allowPackages, ",")) {
+		return nil, nil
+	}
+	allowed := make(map[string]bool)
+	if allowConstructors != "" {
+		for _, name := range strings.Split(allowConstructors, ",") {
+			allowed[name] = true
+		}
+	}
+
+	// The module is loaded on the first call which may be a constructor.
+	var p *program
+	loaded := false
+	load := func() *program {
+		if !loaded {
+			loaded = true
+			p = programOf(pass, ns)
+			if p != nil {
+				for _, c := range p.containers {
+					// The container package calls the constructors by itself.
+					if c.Package == pass.Pkg.Path() {
+						p = nil
+						break
+					}
+				}
+			}
+		}
+		return p
+	}
+
+	for _, file := range pass.Files {
+		if strings.HasSuffix(pass.Fset.File(file.Pos()).Name(), "_test.go") {
+			continue
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+			if !ok || fn.Pkg() == nil || !fn.Exported() {
+				return true
+			}
+			if sig := fn.Type().(*types.Signature); sig.Recv() != nil || sig.Results().Len() != 1 {
+				return true
+			}
+			name := fn.Pkg().Path() + "." + fn.Name()
+			if allowed[name] || load() == nil {
+				return true
+			}
+
+			managers := p.managers[name]
+			if len(managers) == 0 {
+				return true
+			}
+			names := make([]string, 0, len(managers))
+			for _, c := range managers {
+				names = append(names, c.Package+"."+c.Name)
+			}
+			pass.Reportf(call.Pos(), "%s.%s is managed by %s, take the value as a param or resolve it from the container instead of calling it directly",
+				fn.Pkg().Name(), fn.Name(), strings.Join(names, ", "))
+			return true
+		})
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,152 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/yuemori/blueprinter/internal/logger"
+	"github.com/yuemori/blueprinter/internal/parser"
+	"github.com/yuemori/blueprinter/internal/resolver"
+	"golang.org/x/tools/go/analysis"
+)
+
+// A program is a module loaded by the parser, which is needed to check the annotations referring to other packages,
+// such as the targets of `provider:resolve` and the constructors marked as `provider:must_resolve`.
+type program struct {
+	cache *parser.ObjectCache
+	// failures are the errors of the constructors marked as `provider:must_resolve` for each container,
+	// keyed by 'path/to/package.Name'.
+	failures map[string][]error
+	// managers are the containers calling each constructor, keyed by 'path/to/package.Name'.
+	managers map[string][]*parser.Container
+	// containers are the containers resolved, including the ones with errors in some of the constructors.
+	containers []*parser.Container
+}
+
+// LoadModule enables the checks loading the module containing the analyzed package, which are
+// the targets of `provider:resolve` in other packages, `provider:must_resolve`, `provider:strict` and DirectCallAnalyzer.
+// The module is loaded once and shared by the packages analyzed in the same process, like a standalone checker
+// or golangci-lint. It should be disabled if each package is analyzed by its own process like `go vet -vettool`,
+// where the module would be loaded again for every package.
+var LoadModule = true
+
+var programs = struct {
+	sync.Mutex
+	m map[string]*program
+}{m: make(map[string]*program)}
+
+// programOf returns the module of the package analyzed by 'pass', or nil if it cannot be loaded or LoadModule is disabled.
+func programOf(pass *analysis.Pass, ns parser.Namespace) *program {
+	if !LoadModule || len(pass.Files) == 0 {
+		return nil
+	}
+	dir := filepath.Dir(pass.Fset.File(pass.Files[0].Pos()).Name())
+	names := make([]string, 0)
+	if containers != "" {
+		names = strings.Split(containers, ",")
+	}
+	return loadProgram(dir, ns, names)
+}
+
+// loadProgram returns the module containing 'dir', or nil if it cannot be loaded.
+// Modules are loaded once and shared by the packages analyzed in the same process.
+func loadProgram(dir string, ns parser.Namespace, containers []string) *program {
+	modDir := moduleDir(dir)
+	if modDir == "" {
+		return nil
+	}
+	key := fmt.Sprintf("%s|%s|%t|%s", modDir, ns.Prefix, ns.Legacy, strings.Join(containers, ","))
+
+	programs.Lock()
+	defer programs.Unlock()
+	if p, ok := programs.m[key]; ok {
+		return p
+	}
+
+	p, errs := newProgram(modDir, ns, containers)
+	if errs != nil {
+		// Errors in the other packages are reported by analyzing them.
+		logger.Debug("Skip(unable to load the module):", modDir, errs)
+	}
+	programs.m[key] = p
+	return p
+}
+
+func newProgram(modDir string, ns parser.Namespace, containers []string) (*program, []error) {
+	ctx := context.Background()
+	env := os.Environ()
+
+	cache, errs := parser.Parse(ctx, &parser.Config{Dir: modDir, Env: env, Namespace: ns, IgnoreDirectiveErrors: true})
+	if errs != nil {
+		return nil, errs
+	}
+
+	cs := make([]*parser.Container, 0)
+	if len(containers) == 0 {
+		found, err := cache.Containers()
+		if err != nil {
+			return nil, []error{err}
+		}
+		cs = found
+	}
+	for _, name := range containers {
+		i := strings.LastIndex(name, ".")
+		if i < 0 {
+			return nil, []error{fmt.Errorf("container must be like path/to/package.Container, but: %s", name)}
+		}
+		c, errs := parser.LoadContainer(ctx, modDir, env, name[:i], name[i+1:], ns)
+		if errs != nil {
+			return nil, errs
+		}
+		cs = append(cs, c)
+	}
+
+	p := &program{
+		cache:    cache,
+		failures: make(map[string][]error),
+		managers: make(map[string][]*parser.Container),
+	}
+	for _, c := range cs {
+		// The graph has the constructors resolved in spite of the errors, which are still managed by the container.
+		graph, err, errs := resolver.ResolveGraph(cache.InScope(c).WithProfile(""), c.Name, c.Package)
+		if err != nil {
+			logger.Debug("Skip(unable to resolve):", c.Package, c.Name, err)
+			continue
+		}
+		for _, err := range errs {
+			var mre *resolver.MustResolveError
+			if errors.As(err, &mre) {
+				key := mre.Pkg + "." + mre.Name
+				p.failures[key] = append(p.failures[key], fmt.Errorf("in %s.%s: %w", c.Package, c.Name, mre.Err))
+			}
+		}
+		p.containers = append(p.containers, c)
+		for _, n := range graph.Nodes {
+			// Instances of a generic constructor are managed as the generic function called directly.
+			name := n.ImportPath() + "." + n.Name()
+			if managers := p.managers[name]; len(managers) == 0 || managers[len(managers)-1] != c {
+				p.managers[name] = append(managers, c)
+			}
+		}
+	}
+	return p, nil
+}
+
+// moduleDir returns the directory containing go.mod found from 'dir', or an empty string.
+func moduleDir(dir string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,76 @@
+/* provider:include */ // want `provider:include on the package clause is never read by blueprinter`
+package annotations
+
+import "github.com/yuemori/blueprinter/blueprint"
+
+type Store interface {
+	Get(key string) string
+}
+
+type Cache struct{}
+
+func (c *Cache) Get(key string) string {
+	return key
+}
+
+/* provider:primary */  // want `provider:primary on the method Close is never read by blueprinter`
+func (c *Cache) Close() {}
+
+/* provider:exlude */ // want `unknown directive provider:exlude, did you mean provider:exclude\?`
+func NewCache() *Cache {
+	return &Cache{}
+}
+
+/* provider:include */ // want `provider:include on the unexported newCache is never read by blueprinter`
+func newCache() *Cache {
+	return &Cache{}
+}
+
+/* provider:include */ // want `provider:include on the var declaration is never read by blueprinter`
+var DefaultCache = NewCache()
+
+/* provider:exclude */ // want `provider:exclude has no effect, since Version is not a constructor`
+func Version() string {
+	return "v1"
+}
+
+/* provider:resolve NewMissing */ // want `provider:resolve: NewMissing is not found in example.com/app/annotations`
+type Finder interface {
+	Find(key string) string
+}
+
+/* provider:resolve NewCache */ // want `provider:resolve: example.com/app/annotations.NewCache returns .*Cache, which does not implement .*Loader`
+type Loader interface {
+	Find(key string) string
+}
+
+/* provider:resolve NewCache */
+type Getter interface {
+	Get(key string) string
+}
+
+func NewAny[T any]() *T {
+	return new(T)
+}
+
+type Memory struct{}
+
+func NewMemory(c *Cache) Memory {
+	return Memory{}
+}
+
+var _ = blueprint.Bind[Store](NewCache)
+
+var _ = blueprint.Bind[Store](NewMemory) // want `blueprint.Bind: NewMemory returns .*Memory, which does not implement .*Store`
+
+var _ = blueprint.Bind[*Cache](NewCache) // want `blueprint.Bind: .*Cache is not a named interface`
+
+var _ = blueprint.Bind[Store]((*Cache).Get) // want `blueprint.Bind: Get is a method, but the constructor must be a function`
+
+var _ = blueprint.Bind[Store](NewAny[Cache]) // want `blueprint.Bind: NewAny is generic, but the constructor must be a non-generic function`
+
+var _ = blueprint.Bind[Store](newCache) // want `blueprint.Bind: newCache is unexported and cannot be called from the container`
+
+func Bind() {
+	_ = blueprint.Bind[Store](NewCache) // want `blueprint.Bind is never read by blueprinter`
+}
@@ -0,0 +1,8 @@
+// Package blueprint is a stub of github.com/yuemori/blueprinter/blueprint, so that the testdata module has no other requirements.
+package blueprint
+
+type Binding struct{}
+
+func Bind[T any](constructor interface{}) Binding {
+	return Binding{}
+}
@@ -0,0 +1,3 @@
+module github.com/yuemori/blueprinter
+
+go 1.18
@@ -0,0 +1,6 @@
+package container
+
+// Container resolves the constructors in repo and service.
+// blueprinter:container
+// blueprinter:scan ./repo/... ./service/...
+type Container struct{}
@@ -0,0 +1,7 @@
+module example.com/app
+
+go 1.18
+
+require github.com/yuemori/blueprinter v0.0.0
+
+replace github.com/yuemori/blueprinter => ./blueprinter
@@ -0,0 +1,18 @@
+package handler
+
+import (
+	"example.com/app/repo"
+	"example.com/app/service"
+)
+
+type Handler struct {
+	service *service.Service
+}
+
+func NewHandler(s *service.Service) *Handler {
+	return &Handler{service: s}
+}
+
+func NewDefaultHandler() *Handler {
+	return NewHandler(service.NewService(repo.NewRepo())) // want `service.NewService is managed by example.com/app/container.Container` `repo.NewRepo is managed by example.com/app/container.Container`
+}
@@ -0,0 +1,12 @@
+package repo
+
+type Repo struct{}
+
+// provider:include
+func NewRepo() *Repo {
+	return &Repo{}
+}
+
+func (r *Repo) Find(id int) string {
+	return "user"
+}
@@ -0,0 +1,26 @@
+package service
+
+type Repository interface {
+	Find(id int) string
+}
+
+type Service struct {
+	repo Repository
+}
+
+func NewService(r Repository) *Service {
+	return &Service{repo: r}
+}
+
+type Sender interface {
+	Send(msg string)
+}
+
+type Mailer struct {
+	sender Sender
+}
+
+// provider:must_resolve
+func NewMailer(s Sender) *Mailer { // want `NewMailer is marked as provider:must_resolve, but cannot be resolved in example.com/app/container.Container`
+	return &Mailer{sender: s}
+}
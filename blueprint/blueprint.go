@@ -0,0 +1,18 @@
+// Package blueprint provides markers to declare bindings in Go code instead of comment annotations.
+//
+// The markers do nothing at runtime. They are read from the typed AST by the generate command,
+// so the interfaces are checked by the compiler and the constructors are renamed by gopls.
+// The constructors are given as interface{}, and checked by the generate command and the blueprinter analyzer:
+//
+//	var _ = blueprint.Bind[handler.UserRepository](repository.NewUserRepository)
+package blueprint
+
+// A Binding is a marker returned by Bind.
+type Binding struct{}
+
+// Bind binds the interface T to the constructor, like `provider:resolve` on T.
+// The declaration must be a package level variable, and the constructor must be an exported function
+// returning a single value which implements T.
+func Bind[T any](constructor interface{}) Binding {
+	return Binding{}
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yuemori/blueprinter/internal/diagnostic"
+)
+
+var diagnosticsFormat string
+
+// addDiagnosticsFormatFlag adds the --diagnostics-format flag to 'cmd', which is checked before running it.
+func addDiagnosticsFormatFlag(cmd *cobra.Command, persistent bool) {
+	flags := cmd.Flags()
+	if persistent {
+		flags = cmd.PersistentFlags()
+	}
+	flags.StringVar(&diagnosticsFormat, "diagnostics-format", string(diagnostic.FormatText), "Output format of the errors, text, json or sarif")
+
+	cmd.PreRun = func(cmd *cobra.Command, args []string) {
+		if _, err := diagnostic.ParseFormat(diagnosticsFormat); err != nil {
+			// The error itself is reported in the default format.
+			diagnosticsFormat = string(diagnostic.FormatText)
+			reportErrors([]error{fmt.Errorf("--diagnostics-format: %w", err)})
+		}
+	}
+}
+
+// reportErrors writes 'errs' to stdout as the diagnostics in --diagnostics-format, and exits with 1.
+func reportErrors(errs []error) {
+	reportDiagnostics(diagnostic.FromErrors(errs))
+	os.Exit(1)
+}
+
+// reportDiagnostics writes 'diags' to stdout in --diagnostics-format, which is validated before running the command.
+func reportDiagnostics(diags []*diagnostic.Diagnostic) {
+	if err := diagnostic.Write(os.Stdout, diagnostic.Format(diagnosticsFormat), diags); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -5,37 +5,42 @@ package cmd
 
 import (
 	"bytes"
-	"fmt"
+	"errors"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
+	"unicode"
 
 	"github.com/spf13/cobra"
 	"github.com/yuemori/blueprinter/internal/logger"
+	"github.com/yuemori/blueprinter/internal/parser"
 	"github.com/yuemori/blueprinter/internal/runner"
 )
 
 var (
-	verbose                              bool
-	template, workdir, glob, ignore, out string
+	verbose, workspace, all, legacyAnnotations                                       bool
+	template, workdir, glob, ignore, include, out, profile, annotationPrefix, strict string
 )
 
 // generateCmd represents the generate command
 var generateCmd = &cobra.Command{
-	Use:   "generate <path/to/package> <container struct name>",
+	Use:   "generate <path/to/package> [container struct name]",
 	Short: "Generate DI container code",
-	Long:  "Generate DI container code",
-	Args:  cobra.ExactArgs(2),
+	Long: "Generate DI container code.\n" +
+		"If the container struct name is omitted, the struct having blueprinter: directives in its doc comment is used.\n" +
+		"With --all, all structs marked as blueprinter:container in the scanned packages are generated.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if all {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.RangeArgs(1, 2)(cmd, args)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		if verbose {
 			logger.SetVerbose(true)
 		}
 
-		var b bytes.Buffer
-
-		packagePath := args[0]
-		structName := args[1]
-
 		globs := []string{}
 		if glob != "" {
 			globs = strings.Split(glob, ",")
@@ -46,6 +51,16 @@ var generateCmd = &cobra.Command{
 			ignores = strings.Split(ignore, ",")
 		}
 
+		includes := []string{}
+		if include != "" {
+			includes = strings.Split(include, ",")
+		}
+
+		stricts := []string{}
+		if strict != "" {
+			stricts = strings.Split(strict, ",")
+		}
+
 		t := runner.DefaultTemplate
 
 		if template != "" {
@@ -57,45 +72,226 @@ var generateCmd = &cobra.Command{
 			t = string(bytes)
 		}
 
+		profiles := []string{}
+		if profile != "" {
+			profiles = strings.Split(profile, ",")
+		}
+		for _, p := range profiles {
+			if err := parser.ValidateProfile(p); err != nil {
+				reportErrors([]error{err})
+			}
+		}
+
+		ns := namespace()
+
+		if all {
+			if out != "" {
+				log.Fatal("--out cannot be used with --all, use blueprinter:out directive instead")
+			}
+			cfg := &runner.Config{
+				Template:  t,
+				WorkDir:   workdir,
+				Globs:     globs,
+				Ignores:   ignores,
+				Includes:  includes,
+				Workspace: workspace,
+				Namespace: ns,
+				Strict:    stricts,
+			}
+			generateAll(cfg, profiles)
+			return
+		}
+
+		packagePath := args[0]
+		structName := ""
+		if len(args) > 1 {
+			structName = args[1]
+		}
+
+		container, errs := runner.LoadContainer(workdir, packagePath, structName, ns)
+		if errs != nil {
+			reportErrors(errs)
+		}
+		structName = container.Name
+		includes = append(includes, container.Includes...)
+		// Flags take precedence over the directives.
+		if out == "" {
+			out = container.Out
+		}
+
 		cfg := &runner.Config{
 			Template:         t,
-			Dest:             &b,
 			WorkDir:          workdir,
 			Globs:            globs,
 			Ignores:          ignores,
+			Includes:         includes,
+			Scans:            container.Scans,
+			Excludes:         container.Excludes,
+			Workspace:        workspace,
 			ContainerName:    structName,
 			ContainerPackage: packagePath,
+			Namespace:        ns,
+			Architecture:     container.Architecture,
+			Strict:           stricts,
 		}
 
-		errs := runner.Run(cfg)
+		// A single profile (or no profile) produces a single container.
+		if len(profiles) <= 1 {
+			if len(profiles) == 1 {
+				cfg.Profile = profiles[0]
+			}
+			generate(cfg, out)
+			return
+		}
 
+		// Multiple profiles produce a container for each profile from the packages parsed once.
+		if out == "" {
+			log.Fatal("--out is required when multiple profiles are given")
+		}
+		cache, errs := runner.Parse(cfg)
 		if errs != nil {
-			for _, err := range errs {
-				fmt.Println(err)
-			}
+			reportErrors(errs)
+		}
+		// The packages given by the flags are in the scope of the container as well as its directives.
+		container.Includes = includes
+		targets, outs := profiledTargets(container, out, profiles)
+		generateTargets(cache, t, targets, outs)
+	},
+}
+
+// generate runs the runner with cfg and writes the generated code to out, or stdout if out is empty.
+func generate(cfg *runner.Config, out string) {
+	var b bytes.Buffer
+	cfg.Dest = &b
+
+	errs := runner.Run(cfg)
+
+	if errs != nil {
+		reportErrors(errs)
+	}
+
+	write(b.Bytes(), out)
+}
+
+// generateAll generates all containers marked as blueprinter:container in the packages scanned once.
+// Each container is written to the path given by blueprinter:out, or '<snake_case_name>.generated.go' in its package.
+func generateAll(cfg *runner.Config, profiles []string) {
+	cache, containers, errs := runner.ParseContainers(cfg)
+	if errs != nil {
+		reportErrors(errs)
+	}
+	if len(containers) == 0 {
+		reportErrors([]error{errors.New("no struct marked as blueprinter:container is found")})
+	}
 
-			os.Exit(1)
+	targets := make([]*runner.Target, 0)
+	outs := make([]string, 0)
+	for _, c := range containers {
+		path := c.Out
+		if path == "" {
+			path = filepath.Join(cache.Dir(c.Package), snakeCase(c.Name)+".generated.go")
 		}
+		ts, paths := profiledTargets(c, path, profiles)
+		targets = append(targets, ts...)
+		outs = append(outs, paths...)
+	}
 
-		dest := os.Stdout
+	generateTargets(cache, cfg.Template, targets, outs)
+}
 
-		if out != "" {
-			fp, err := os.OpenFile(out, os.O_TRUNC|os.O_CREATE|os.O_RDWR, 0o664)
-			if err != nil {
-				log.Fatal(err)
-			}
-			dest = fp
-			defer dest.Close()
+// profiledTargets returns the targets of 'c' for 'profiles' and the paths to write them based on 'path'.
+//
+// Multiple profiles produce a file for each profile like 'container_dev.generated.go', and the default file
+// without profiles at 'path'. Their build constraints are exclusive and cover every combination of the tags:
+// the file of a profile requires its tag and none of the tags of the profiles before it,
+// and the default file requires none of the tags.
+func profiledTargets(c *parser.Container, path string, profiles []string) ([]*runner.Target, []string) {
+	if len(profiles) <= 1 {
+		target := &runner.Target{Dest: &bytes.Buffer{}, Container: c}
+		if len(profiles) == 1 {
+			target.Profile = profiles[0]
 		}
+		return []*runner.Target{target}, []string{path}
+	}
+
+	targets := make([]*runner.Target, 0, len(profiles)+1)
+	outs := make([]string, 0, len(profiles)+1)
+	excluded := make([]string, 0, len(profiles))
+	for _, p := range profiles {
+		tags := append([]string{p}, excluded...)
+		targets = append(targets, &runner.Target{Dest: &bytes.Buffer{}, Container: c, Profile: p, BuildTags: tags})
+		outs = append(outs, profiledPath(path, p))
+		excluded = append(excluded, "!"+p)
+	}
+	targets = append(targets, &runner.Target{Dest: &bytes.Buffer{}, Container: c, BuildTags: excluded})
+	outs = append(outs, path)
+	return targets, outs
+}
+
+// generateTargets generates 'targets' from 'cache' and writes each of them to the path of the same index in 'outs'.
+func generateTargets(cache *parser.ObjectCache, tmpl string, targets []*runner.Target, outs []string) {
+	if errs := runner.Generate(cache, tmpl, targets); errs != nil {
+		reportErrors(errs)
+	}
+
+	for i, target := range targets {
+		write(target.Dest.(*bytes.Buffer).Bytes(), outs[i])
+	}
+}
 
-		if _, err := dest.Write(b.Bytes()); err != nil {
+// write writes the generated code to out, or stdout if out is empty.
+func write(code []byte, out string) {
+	dest := os.Stdout
+
+	if out != "" {
+		fp, err := os.OpenFile(out, os.O_TRUNC|os.O_CREATE|os.O_RDWR, 0o664)
+		if err != nil {
 			log.Fatal(err)
 		}
+		dest = fp
+		defer dest.Close()
+	}
+
+	if _, err := dest.Write(code); err != nil {
+		log.Fatal(err)
+	}
+
+	if out != "" {
+		logger.Infof("Generated code is written to %s", out)
+	}
+}
+
+// namespace returns the namespace of the provider directives given by --annotation-prefix and --legacy-annotations.
+func namespace() parser.Namespace {
+	ns := parser.Namespace{Prefix: annotationPrefix, Legacy: legacyAnnotations}
+	if err := ns.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	return ns
+}
 
-		if out != "" {
-			logger.Infof("Generated code is written to %s", out)
+// snakeCase returns a string like 'app_container' for 'AppContainer'.
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
 		}
-	},
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// profiledPath returns a path like 'container_dev.generated.go' for 'container.generated.go'.
+func profiledPath(path, profile string) string {
+	dir, base := filepath.Split(path)
+	if i := strings.Index(base, "."); i >= 0 {
+		return filepath.Join(dir, base[:i]+"_"+profile+base[i:])
+	}
+	return filepath.Join(dir, base+"_"+profile)
 }
 
 func init() {
@@ -104,6 +300,15 @@ func init() {
 	generateCmd.PersistentFlags().StringVarP(&template, "template", "t", "", "Template file for generating code. If not speicied, use default template")
 	generateCmd.PersistentFlags().StringVarP(&workdir, "workdir", "w", ".", "Workdir for generating code. If not specified, use current directory")
 	generateCmd.PersistentFlags().StringVarP(&ignore, "ignore", "i", "", "Glob pattern for ignoring files")
+	generateCmd.PersistentFlags().StringVar(&include, "include-packages", "", "Comma separated import path patterns of packages to be scanned in addition to workdir, like github.com/owner/repo/...")
 	generateCmd.PersistentFlags().StringVarP(&out, "out", "o", "", "Output file for generated code. If not specified, output to stdout")
+	generateCmd.PersistentFlags().StringVarP(&profile, "profile", "p", "", "Comma separated profiles for selecting objects annotated with provider:profile. If multiple profiles are specified, generate a file guarded by the build tag for each profile, preferring the earlier ones, and a default file for no tag")
+	generateCmd.PersistentFlags().BoolVar(&all, "all", false, "Generate all structs marked as blueprinter:container in the scanned packages")
+	generateCmd.PersistentFlags().BoolVar(&workspace, "workspace", false, "Scan all modules listed in go.work found from workdir")
+	generateCmd.PersistentFlags().StringVar(&strict, "strict", "", "Comma separated import path patterns like github.com/owner/repo/..., or directories like ./internal/... relative to workdir, of the packages whose exported constructors must be resolved, like the packages marked as provider:strict")
+	generateCmd.PersistentFlags().Lookup("strict").NoOptDefVal = "./..."
+	generateCmd.PersistentFlags().StringVar(&annotationPrefix, "annotation-prefix", parser.LegacyPrefix, "Prefix of the annotations like provider:resolve, e.g. blueprinter:")
+	generateCmd.PersistentFlags().BoolVar(&legacyAnnotations, "legacy-annotations", true, "Accept the annotations prefixed by "+parser.LegacyPrefix+" as well as --annotation-prefix")
 	generateCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Verbose mode")
+	addDiagnosticsFormatFlag(generateCmd, true)
 }
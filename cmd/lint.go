@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yuemori/blueprinter/internal/logger"
+	"github.com/yuemori/blueprinter/internal/parser"
+	"github.com/yuemori/blueprinter/internal/runner"
+)
+
+// lintCmd represents the lint command
+var lintCmd = &cobra.Command{
+	Use:   "lint [path/to/package] [container struct name]",
+	Short: "Check the dependencies of DI containers without generating code",
+	Long: "Resolve the dependencies of DI containers and report the errors, including the dependencies\n" +
+		"violating the layer rules given by blueprinter:layer, blueprinter:allow and blueprinter:deny.\n" +
+		"If the package is omitted, all structs marked as blueprinter:container in the scanned packages are checked.",
+	Args: cobra.RangeArgs(0, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if verbose {
+			logger.SetVerbose(true)
+		}
+
+		if profile != "" {
+			if err := parser.ValidateProfile(profile); err != nil {
+				reportErrors([]error{err})
+			}
+		}
+
+		cache, containers := loadContainers(args)
+
+		if errs := runner.Lint(cache, containers, profile); errs != nil {
+			reportErrors(errs)
+		}
+	},
+}
+
+// loadContainers parses the packages for the container given by 'args' as '[path/to/package] [container struct name]',
+// or for all structs marked as blueprinter:container if 'args' is empty.
+func loadContainers(args []string) (*parser.ObjectCache, []*parser.Container) {
+	ignores := []string{}
+	if ignore != "" {
+		ignores = strings.Split(ignore, ",")
+	}
+
+	includes := []string{}
+	if include != "" {
+		includes = strings.Split(include, ",")
+	}
+
+	stricts := []string{}
+	if strict != "" {
+		stricts = strings.Split(strict, ",")
+	}
+
+	ns := namespace()
+	cfg := &runner.Config{
+		WorkDir:   workdir,
+		Ignores:   ignores,
+		Includes:  includes,
+		Workspace: workspace,
+		Namespace: ns,
+		Strict:    stricts,
+	}
+
+	if len(args) == 0 {
+		cache, containers, errs := runner.ParseContainers(cfg)
+		if errs != nil {
+			reportErrors(errs)
+		}
+		if len(containers) == 0 {
+			reportErrors([]error{errors.New("no struct marked as blueprinter:container is found")})
+		}
+		return cache, containers
+	}
+
+	structName := ""
+	if len(args) > 1 {
+		structName = args[1]
+	}
+	container, errs := runner.LoadContainer(workdir, args[0], structName, ns)
+	if errs != nil {
+		reportErrors(errs)
+	}
+	cfg.Includes = append(cfg.Includes, container.Includes...)
+	cfg.Scans = container.Scans
+	cfg.Excludes = container.Excludes
+	cfg.ContainerPackage = container.Package
+
+	cache, errs := runner.Parse(cfg)
+	if errs != nil {
+		reportErrors(errs)
+	}
+	containers := []*parser.Container{container}
+
+	return cache, containers
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+
+	lintCmd.Flags().StringVarP(&workdir, "workdir", "w", ".", "Workdir for loading packages. If not specified, use current directory")
+	lintCmd.Flags().StringVarP(&ignore, "ignore", "i", "", "Glob pattern for ignoring files")
+	lintCmd.Flags().StringVar(&include, "include-packages", "", "Comma separated import path patterns of packages to be scanned in addition to workdir, like github.com/owner/repo/...")
+	lintCmd.Flags().StringVarP(&profile, "profile", "p", "", "Profile for selecting objects annotated with provider:profile")
+	lintCmd.Flags().BoolVar(&workspace, "workspace", false, "Scan all modules listed in go.work found from workdir")
+	lintCmd.Flags().StringVar(&strict, "strict", "", "Comma separated import path patterns like github.com/owner/repo/..., or directories like ./internal/... relative to workdir, of the packages whose exported constructors must be resolved, like the packages marked as provider:strict")
+	lintCmd.Flags().Lookup("strict").NoOptDefVal = "./..."
+	lintCmd.Flags().StringVar(&annotationPrefix, "annotation-prefix", parser.LegacyPrefix, "Prefix of the annotations like provider:resolve, e.g. blueprinter:")
+	lintCmd.Flags().BoolVar(&legacyAnnotations, "legacy-annotations", true, "Accept the annotations prefixed by "+parser.LegacyPrefix+" as well as --annotation-prefix")
+	lintCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose mode")
+	addDiagnosticsFormatFlag(lintCmd, false)
+}
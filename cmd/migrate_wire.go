@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yuemori/blueprinter/internal/logger"
+	"github.com/yuemori/blueprinter/internal/parser"
+	"github.com/yuemori/blueprinter/internal/runner"
+)
+
+var containerName string
+
+// migrateWireCmd represents the migrate-wire command
+var migrateWireCmd = &cobra.Command{
+	Use:   "migrate-wire <path/to/package> <injector func name>",
+	Short: "Convert a wire injector into a container struct",
+	Long: "Convert an injector function calling wire.Build into a container struct.\n" +
+		"The annotations required to resolve the providers without google/wire are written to stderr.",
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if verbose {
+			logger.SetVerbose(true)
+		}
+
+		ignores := []string{}
+		if ignore != "" {
+			ignores = strings.Split(ignore, ",")
+		}
+
+		includes := []string{}
+		if include != "" {
+			includes = strings.Split(include, ",")
+		}
+
+		var b bytes.Buffer
+		errs := runner.Migrate(&runner.MigrateConfig{
+			Dest:          &b,
+			Notes:         os.Stderr,
+			WorkDir:       workdir,
+			Ignores:       ignores,
+			Includes:      includes,
+			Package:       args[0],
+			Injector:      args[1],
+			ContainerName: containerName,
+			Namespace:     namespace(),
+		})
+		if errs != nil {
+			reportErrors(errs)
+		}
+
+		if out == "" {
+			if _, err := os.Stdout.Write(b.Bytes()); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+
+		if err := os.WriteFile(out, b.Bytes(), 0o664); err != nil {
+			log.Fatal(err)
+		}
+		logger.Infof("Container is written to %s", out)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(migrateWireCmd)
+
+	migrateWireCmd.Flags().StringVarP(&workdir, "workdir", "w", ".", "Workdir for loading packages. If not specified, use current directory")
+	migrateWireCmd.Flags().StringVarP(&ignore, "ignore", "i", "", "Glob pattern for ignoring files")
+	migrateWireCmd.Flags().StringVar(&include, "include-packages", "", "Comma separated import path patterns of packages to be scanned in addition to workdir, like github.com/owner/repo/...")
+	migrateWireCmd.Flags().StringVarP(&out, "out", "o", "", "Output file for the container struct. If not specified, output to stdout")
+	migrateWireCmd.Flags().StringVarP(&containerName, "name", "n", "Container", "Name of the container struct")
+	migrateWireCmd.Flags().StringVar(&annotationPrefix, "annotation-prefix", parser.LegacyPrefix, "Prefix of the annotations like provider:resolve, e.g. blueprinter:")
+	migrateWireCmd.Flags().BoolVar(&legacyAnnotations, "legacy-annotations", true, "Accept the annotations prefixed by "+parser.LegacyPrefix+" as well as --annotation-prefix")
+	migrateWireCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose mode")
+	addDiagnosticsFormatFlag(migrateWireCmd, false)
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yuemori/blueprinter/internal/diagnostic"
+	"github.com/yuemori/blueprinter/internal/logger"
+	"github.com/yuemori/blueprinter/internal/parser"
+	"github.com/yuemori/blueprinter/internal/runner"
+)
+
+var (
+	rewritePrefix string
+	dryRun        bool
+)
+
+// rewriteAnnotationsCmd represents the rewrite-annotations command
+var rewriteAnnotationsCmd = &cobra.Command{
+	Use:   "rewrite-annotations",
+	Short: "Rewrite provider: annotations into another prefix",
+	Long: "Rewrite the annotations like provider:resolve in the doc comments under workdir into another prefix like blueprinter:resolve.\n" +
+		"Run generate with --annotation-prefix while both forms exist, and with --legacy-annotations=false after rewriting.",
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if verbose {
+			logger.SetVerbose(true)
+		}
+
+		ignores := []string{}
+		if ignore != "" {
+			ignores = strings.Split(ignore, ",")
+		}
+
+		rewrites, errs := runner.Rewrite(&runner.RewriteConfig{
+			WorkDir: workdir,
+			Ignores: ignores,
+			Prefix:  rewritePrefix,
+			DryRun:  dryRun,
+		})
+		if errs != nil {
+			reportErrors(errs)
+		}
+
+		warnings := make([]*diagnostic.Diagnostic, 0)
+		for _, r := range rewrites {
+			for _, w := range r.Warnings {
+				d := diagnostic.FromError(w)
+				d.Severity = diagnostic.SeverityWarning
+				warnings = append(warnings, d)
+			}
+			if r.Count == 0 {
+				continue
+			}
+			if dryRun {
+				logger.Infof("%s: %d annotations to be rewritten", r.Path, r.Count)
+			} else {
+				logger.Infof("%s: %d annotations are rewritten", r.Path, r.Count)
+			}
+		}
+		if len(warnings) > 0 {
+			reportDiagnostics(warnings)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rewriteAnnotationsCmd)
+
+	rewriteAnnotationsCmd.Flags().StringVarP(&workdir, "workdir", "w", ".", "Workdir for rewriting annotations. If not specified, use current directory")
+	rewriteAnnotationsCmd.Flags().StringVarP(&ignore, "ignore", "i", "", "Glob pattern for ignoring files")
+	rewriteAnnotationsCmd.Flags().StringVar(&rewritePrefix, "annotation-prefix", parser.ContainerPrefix, "Prefix which "+parser.LegacyPrefix+" annotations are rewritten into")
+	rewriteAnnotationsCmd.Flags().BoolVar(&dryRun, "dry-run", false, "List the files to be rewritten without writing them")
+	rewriteAnnotationsCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose mode")
+	addDiagnosticsFormatFlag(rewriteAnnotationsCmd, false)
+}
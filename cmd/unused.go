@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yuemori/blueprinter/internal/diagnostic"
+	"github.com/yuemori/blueprinter/internal/logger"
+	"github.com/yuemori/blueprinter/internal/parser"
+	"github.com/yuemori/blueprinter/internal/runner"
+)
+
+// unusedCmd represents the unused command
+var unusedCmd = &cobra.Command{
+	Use:   "unused [path/to/package] [container struct name]",
+	Short: "Report constructors, bindings and container fields never used",
+	Long: "Report the constructors never called by any container, the interfaces bound to a constructor but never\n" +
+		"consumed by a param, and the fields of the container never used by a derivation.\n" +
+		"If the package is omitted, all structs marked as blueprinter:container in the scanned packages are checked.\n" +
+		"It exits with 1 if any item is reported.",
+	Args: cobra.RangeArgs(0, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if verbose {
+			logger.SetVerbose(true)
+		}
+
+		if profile != "" {
+			if err := parser.ValidateProfile(profile); err != nil {
+				reportErrors([]error{err})
+			}
+		}
+
+		cache, containers := loadContainers(args)
+
+		unused, errs := runner.FindUnused(cache, containers, profile)
+		if errs != nil {
+			reportErrors(errs)
+		}
+
+		diags := make([]*diagnostic.Diagnostic, 0, len(unused))
+		for _, u := range unused {
+			diags = append(diags, diagnostic.FromUnused(string(u.Kind), u.Name, u.Detail, u.Pos, u.Containers))
+		}
+		reportDiagnostics(diags)
+		if len(diags) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(unusedCmd)
+
+	unusedCmd.Flags().StringVarP(&workdir, "workdir", "w", ".", "Workdir for loading packages. If not specified, use current directory")
+	unusedCmd.Flags().StringVarP(&ignore, "ignore", "i", "", "Glob pattern for ignoring files")
+	unusedCmd.Flags().StringVar(&include, "include-packages", "", "Comma separated import path patterns of packages to be scanned in addition to workdir, like github.com/owner/repo/...")
+	unusedCmd.Flags().StringVarP(&profile, "profile", "p", "", "Profile for selecting objects annotated with provider:profile")
+	unusedCmd.Flags().BoolVar(&workspace, "workspace", false, "Scan all modules listed in go.work found from workdir")
+	unusedCmd.Flags().StringVar(&annotationPrefix, "annotation-prefix", parser.LegacyPrefix, "Prefix of the annotations like provider:resolve, e.g. blueprinter:")
+	unusedCmd.Flags().BoolVar(&legacyAnnotations, "legacy-annotations", true, "Accept the annotations prefixed by "+parser.LegacyPrefix+" as well as --annotation-prefix")
+	unusedCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose mode")
+	addDiagnosticsFormatFlag(unusedCmd, false)
+}
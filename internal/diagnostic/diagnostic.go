@@ -0,0 +1,159 @@
+// Package diagnostic converts the errors of blueprinter into diagnostics with codes and positions,
+// which are rendered as text, JSON or SARIF.
+package diagnostic
+
+import (
+	"errors"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"github.com/yuemori/blueprinter/internal/parser"
+	"github.com/yuemori/blueprinter/internal/resolver"
+	"golang.org/x/tools/go/packages"
+)
+
+// A Severity is the severity of a diagnostic, which is also the level of SARIF.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Codes of the diagnostics.
+const (
+	// CodeError is an error without a specific code.
+	CodeError = "error"
+	// CodeLoad is an error of loading the packages, such as a compile error.
+	CodeLoad = "load-error"
+	// CodeDirective is an unknown or malformed directive.
+	CodeDirective = "invalid-directive"
+	// CodeProvider is an invalid provider given by blueprint.Bind or a provider set of google/wire.
+	CodeProvider = "invalid-provider"
+	// CodeAmbiguous is an interface which has more than one implementation or constructor.
+	CodeAmbiguous = "ambiguous-binding"
+	// CodeParams is a constructor whose params cannot be derived.
+	CodeParams = "unresolved-params"
+	// CodeMustResolve is a constructor marked as `provider:must_resolve` which cannot be resolved.
+	CodeMustResolve = "must-resolve"
+	// CodeStrict is a constructor which cannot be resolved in the strict mode.
+	CodeStrict = "strict"
+	// CodeRoot is a root which cannot be resolved.
+	CodeRoot = "unresolved-root"
+	// CodeLayer is a dependency violating the layer rules.
+	CodeLayer = "layer-violation"
+	// CodeUnused is a constructor, a binding or a field never used by the containers.
+	CodeUnused = "unused"
+)
+
+// A Diagnostic is an error or a warning with its position.
+type Diagnostic struct {
+	Code     string
+	Severity Severity
+	Message  string
+	// Pos is the position of the diagnostic, which is invalid if unknown.
+	Pos     token.Position
+	Related []*Related
+}
+
+// A Related is a position related to a diagnostic, like the candidates of an ambiguous interface.
+type Related struct {
+	Message string
+	Pos     token.Position
+}
+
+// FromErrors returns the diagnostics of 'errs'.
+func FromErrors(errs []error) []*Diagnostic {
+	diags := make([]*Diagnostic, 0, len(errs))
+	for _, err := range errs {
+		diags = append(diags, FromError(err))
+	}
+	return diags
+}
+
+// FromError returns the diagnostic of 'err'. The message is the message of 'err',
+// and the code and the positions are taken from the typed errors wrapped in 'err'.
+func FromError(err error) *Diagnostic {
+	d := &Diagnostic{Code: CodeError, Severity: SeverityError, Message: strings.TrimSpace(err.Error())}
+
+	var (
+		layerErr     *resolver.LayerError
+		mustErr      *resolver.MustResolveError
+		rootErr      *resolver.RootError
+		ambiguousErr *resolver.AmbiguousError
+		paramsErr    *resolver.ParamsError
+		directiveErr *parser.DirectiveError
+		providerErr  *parser.ProviderError
+		loadErr      packages.Error
+	)
+	switch {
+	case errors.As(err, &layerErr):
+		d.Code, d.Pos = CodeLayer, layerErr.FromPos
+		d.Related = append(d.Related,
+			&Related{Message: layerErr.To, Pos: layerErr.ToPos},
+			&Related{Message: layerErr.Rule, Pos: layerErr.RulePos})
+	case errors.As(err, &mustErr):
+		d.Code, d.Pos = CodeMustResolve, mustErr.Pos
+		if mustErr.Strict {
+			d.Code = CodeStrict
+		}
+	case errors.As(err, &rootErr):
+		d.Code, d.Pos = CodeRoot, rootErr.Pos
+	case errors.As(err, &ambiguousErr):
+		d.Code, d.Pos = CodeAmbiguous, ambiguousErr.Pos
+		for _, c := range ambiguousErr.Candidates {
+			d.Related = append(d.Related, &Related{Message: "candidate " + c.Name, Pos: c.Pos})
+		}
+	case errors.As(err, &paramsErr):
+		d.Code, d.Pos = CodeParams, paramsErr.Pos
+	case errors.As(err, &directiveErr):
+		d.Code, d.Pos = CodeDirective, directiveErr.Pos
+		// The position is not repeated in the message.
+		d.Message = strings.Replace(d.Message, directiveErr.Error(), directiveErr.Err.Error(), 1)
+	case errors.As(err, &providerErr):
+		d.Code, d.Pos = CodeProvider, providerErr.Pos
+		d.Message = strings.Replace(d.Message, providerErr.Error(), providerErr.Err.Error(), 1)
+	case errors.As(err, &loadErr):
+		d.Code, d.Pos = CodeLoad, parsePosition(loadErr.Pos)
+		if d.Pos.IsValid() || loadErr.Pos == "" || loadErr.Pos == "-" {
+			d.Message = strings.Replace(d.Message, loadErr.Error(), loadErr.Msg, 1)
+		}
+	}
+	return d
+}
+
+// FromUnused returns the warnings of the items never used by the containers.
+func FromUnused(kind, name, detail string, pos token.Position, containers []string) *Diagnostic {
+	msg := "unused " + kind + " " + name
+	if detail != "" {
+		msg += " (" + detail + ")"
+	}
+	msg += " in " + strings.Join(containers, ", ")
+	return &Diagnostic{Code: CodeUnused, Severity: SeverityWarning, Message: msg, Pos: pos}
+}
+
+// parsePosition parses a position like 'file.go:12:3' reported by go/packages.
+func parsePosition(s string) token.Position {
+	pos := token.Position{}
+	parts := strings.Split(s, ":")
+	// The file name may contain ':', so the numbers are read from the end.
+	nums := make([]int, 0, 2)
+	for len(parts) > 1 && len(nums) < 2 {
+		n, err := strconv.Atoi(parts[len(parts)-1])
+		if err != nil {
+			break
+		}
+		nums = append([]int{n}, nums...)
+		parts = parts[:len(parts)-1]
+	}
+	if len(nums) == 0 || s == "" || s == "-" {
+		return pos
+	}
+	pos.Filename = strings.Join(parts, ":")
+	pos.Line = nums[0]
+	if len(nums) > 1 {
+		pos.Column = nums[1]
+	}
+	return pos
+}
@@ -0,0 +1,242 @@
+package diagnostic
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// A Format is the output format of diagnostics.
+type Format string
+
+const (
+	FormatText  Format = "text"
+	FormatJSON  Format = "json"
+	FormatSARIF Format = "sarif"
+)
+
+// ParseFormat returns the Format named 's'.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case FormatText, FormatJSON, FormatSARIF:
+		return f, nil
+	}
+	return "", fmt.Errorf("format must be text, json or sarif, but: %s", s)
+}
+
+// Write renders 'diags' to 'w' in 'format'.
+func Write(w io.Writer, format Format, diags []*Diagnostic) error {
+	switch format {
+	case FormatJSON:
+		return writeJSON(w, diags)
+	case FormatSARIF:
+		return writeSARIF(w, diags)
+	}
+	return writeText(w, diags)
+}
+
+// writeText writes the diagnostics like 'path/to/file.go:12:3: error: message [code]',
+// followed by the related positions indented.
+func writeText(w io.Writer, diags []*Diagnostic) error {
+	for _, d := range diags {
+		line := fmt.Sprintf("%s: %s [%s]", d.Severity, d.Message, d.Code)
+		if d.Pos.IsValid() {
+			line = d.Pos.String() + ": " + line
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+		for _, r := range d.Related {
+			related := "\t" + r.Message
+			if r.Pos.IsValid() {
+				related = "\t" + r.Pos.String() + ": " + r.Message
+			}
+			if _, err := fmt.Fprintln(w, related); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+type jsonDiagnostic struct {
+	Code     string         `json:"code"`
+	Severity Severity       `json:"severity"`
+	Message  string         `json:"message"`
+	Position *jsonPosition  `json:"position,omitempty"`
+	Related  []*jsonRelated `json:"related,omitempty"`
+}
+
+type jsonRelated struct {
+	Message  string        `json:"message"`
+	Position *jsonPosition `json:"position,omitempty"`
+}
+
+type jsonPosition struct {
+	Filename string `json:"filename"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column,omitempty"`
+}
+
+func newJSONPosition(pos token.Position) *jsonPosition {
+	if !pos.IsValid() {
+		return nil
+	}
+	return &jsonPosition{Filename: pos.Filename, Line: pos.Line, Column: pos.Column}
+}
+
+// writeJSON writes the diagnostics as a JSON array.
+func writeJSON(w io.Writer, diags []*Diagnostic) error {
+	items := make([]*jsonDiagnostic, 0, len(diags))
+	for _, d := range diags {
+		item := &jsonDiagnostic{Code: d.Code, Severity: d.Severity, Message: d.Message, Position: newJSONPosition(d.Pos)}
+		for _, r := range d.Related {
+			item.Related = append(item.Related, &jsonRelated{Message: r.Message, Position: newJSONPosition(r.Pos)})
+		}
+		items = append(items, item)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(items)
+}
+
+// sarifVersion and sarifSchema are the version of SARIF written by writeSARIF.
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+type sarifLog struct {
+	Version string      `json:"version"`
+	Schema  string      `json:"$schema"`
+	Runs    []*sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    *sarifTool     `json:"tool"`
+	Results []*sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver *sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string       `json:"name"`
+	InformationURI string       `json:"informationUri"`
+	Rules          []*sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID           string           `json:"ruleId"`
+	Level            Severity         `json:"level"`
+	Message          *sarifMessage    `json:"message"`
+	Locations        []*sarifLocation `json:"locations,omitempty"`
+	RelatedLocations []*sarifLocation `json:"relatedLocations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	ID               int                    `json:"id,omitempty"`
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation"`
+	Message          *sarifMessage          `json:"message,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation *sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+func newSARIFLocation(pos token.Position) *sarifLocation {
+	return &sarifLocation{
+		PhysicalLocation: &sarifPhysicalLocation{
+			ArtifactLocation: &sarifArtifactLocation{URI: artifactURI(pos.Filename)},
+			Region:           &sarifRegion{StartLine: pos.Line, StartColumn: pos.Column},
+		},
+	}
+}
+
+// artifactURI returns the path of 'filename' relative to the working directory, which code scanning expects
+// to be relative to the root of the repository, or the absolute path if it is out of the working directory.
+func artifactURI(filename string) string {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return filepath.ToSlash(filename)
+	}
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, "../") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return "file://" + filepath.ToSlash(abs)
+}
+
+// writeSARIF writes the diagnostics as a SARIF log for code scanning.
+func writeSARIF(w io.Writer, diags []*Diagnostic) error {
+	codes := make(map[string]bool)
+	results := make([]*sarifResult, 0, len(diags))
+	for _, d := range diags {
+		codes[d.Code] = true
+		result := &sarifResult{RuleID: d.Code, Level: d.Severity, Message: &sarifMessage{Text: d.Message}}
+		if d.Pos.IsValid() {
+			result.Locations = append(result.Locations, newSARIFLocation(d.Pos))
+		}
+		for _, r := range d.Related {
+			if !r.Pos.IsValid() {
+				continue
+			}
+			loc := newSARIFLocation(r.Pos)
+			loc.ID = len(result.RelatedLocations) + 1
+			loc.Message = &sarifMessage{Text: r.Message}
+			result.RelatedLocations = append(result.RelatedLocations, loc)
+		}
+		results = append(results, result)
+	}
+
+	rules := make([]*sarifRule, 0, len(codes))
+	for code := range codes {
+		rules = append(rules, &sarifRule{ID: code})
+	}
+	sort.SliceStable(rules, func(x, y int) bool {
+		return rules[x].ID < rules[y].ID
+	})
+
+	log := &sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs: []*sarifRun{{
+			Tool: &sarifTool{Driver: &sarifDriver{
+				Name:           "blueprinter",
+				InformationURI: "https://github.com/yuemori/blueprinter",
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
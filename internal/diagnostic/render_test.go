@@ -0,0 +1,72 @@
+package diagnostic
+
+import (
+	"bytes"
+	"flag"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// testDiagnostics are the diagnostics rendered by the golden tests, with the positions relative to the package directory.
+var testDiagnostics = []*Diagnostic{
+	{
+		Code:     CodeAmbiguous,
+		Severity: SeverityError,
+		Message:  "multiple implementations found for example.com/app/service.Repository",
+		Pos:      token.Position{Filename: "service/service.go", Line: 3, Column: 6},
+		Related: []*Related{
+			{Message: "candidate example.com/app/repo.NewMemory", Pos: token.Position{Filename: "repo/memory.go", Line: 5, Column: 6}},
+			{Message: "candidate example.com/app/repo.NewRepo", Pos: token.Position{Filename: "repo/repo.go", Line: 7}},
+			{Message: "candidate example.com/lib.NewRepo"},
+		},
+	},
+	{
+		Code:     CodeUnused,
+		Severity: SeverityWarning,
+		Message:  "unused constructor example.com/app/repo.NewCache in example.com/app/container.Container",
+		Pos:      token.Position{Filename: "repo/cache.go", Line: 10, Column: 6},
+	},
+	{
+		Code:     CodeError,
+		Severity: SeverityError,
+		Message:  "container must be like path/to/package.Container, but: Container",
+	},
+}
+
+func TestWriteGolden(t *testing.T) {
+	tests := []struct {
+		format Format
+		golden string
+	}{
+		{format: FormatText, golden: "diagnostics.txt.golden"},
+		{format: FormatJSON, golden: "diagnostics.json.golden"},
+		{format: FormatSARIF, golden: "diagnostics.sarif.golden"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, tt.format, testDiagnostics); err != nil {
+				t.Fatal(err)
+			}
+
+			path := filepath.Join("testdata", tt.golden)
+			if *update {
+				if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != string(want) {
+				t.Errorf("Write(%s) = \n%s\nwant %s:\n%s", tt.format, got, path, want)
+			}
+		})
+	}
+}
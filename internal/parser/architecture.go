@@ -0,0 +1,167 @@
+package parser
+
+import (
+	"fmt"
+	"go/token"
+	"path/filepath"
+	"strings"
+)
+
+// A Layer is a set of packages named by the 'layer' directive of the container struct:
+//
+//	// blueprinter:layer handler ./internal/handler/...
+//	// blueprinter:layer service ./internal/service/...
+//	// blueprinter:layer repository ./internal/repository/... github.com/owner/repo/db/...
+//
+// Paths are relative to the root of the module like 'scan', and the others are import path patterns.
+type Layer struct {
+	Name string
+	// Dirs are the directories of the layer.
+	Dirs []DirPattern
+	// Packages are the import path patterns of the layer.
+	Packages []string
+}
+
+// Contains returns true if the package 'pkgPath' in the directory 'dir' belongs to the layer.
+// 'dir' may be empty if the directory of the package is unknown.
+func (l *Layer) Contains(pkgPath, dir string) bool {
+	if MatchesImportPath(pkgPath, l.Packages) {
+		return true
+	}
+	if dir == "" {
+		return false
+	}
+	for _, p := range l.Dirs {
+		if p.Match(dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// A LayerRule is a rule between the layers given by the 'allow' or 'deny' directive:
+//
+//	// blueprinter:allow handler service
+//	// blueprinter:deny domain infra
+//
+// 'allow' makes the first layer depend only on the other layers and itself,
+// and 'deny' makes the first layer not depend on the other layers.
+type LayerRule struct {
+	From string
+	To   []string
+	// Allow is true for 'allow', and false for 'deny'.
+	Allow bool
+	Pos   token.Position
+}
+
+func (r *LayerRule) String() string {
+	name := DirectiveDeny
+	if r.Allow {
+		name = DirectiveAllow
+	}
+	return strings.Join(append([]string{name, r.From}, r.To...), " ")
+}
+
+// An Architecture is the layers and the rules between them declared on the container struct.
+// Packages in no layer are not restricted.
+type Architecture struct {
+	Layers []*Layer
+	Rules  []*LayerRule
+}
+
+// LayerOf returns the first layer containing the package 'pkgPath' in the directory 'dir', or nil.
+func (a *Architecture) LayerOf(pkgPath, dir string) *Layer {
+	if a == nil {
+		return nil
+	}
+	for _, l := range a.Layers {
+		if l.Contains(pkgPath, dir) {
+			return l
+		}
+	}
+	return nil
+}
+
+// Violation returns the rule violated by a dependency from the layer 'from' to the layer 'to', or nil.
+// Dependencies within a layer and from or to no layer never violate the rules.
+func (a *Architecture) Violation(from, to *Layer) *LayerRule {
+	if a == nil || from == nil || to == nil || from == to {
+		return nil
+	}
+
+	var allow *LayerRule
+	allowed := false
+	for _, r := range a.Rules {
+		if r.From != from.Name {
+			continue
+		}
+		if !r.Allow {
+			if contains(r.To, to.Name) {
+				return r
+			}
+			continue
+		}
+		if allow == nil {
+			allow = r
+		}
+		allowed = allowed || contains(r.To, to.Name)
+	}
+	if allow != nil && !allowed {
+		return allow
+	}
+	return nil
+}
+
+// IsEmpty returns true if no rule is declared.
+func (a *Architecture) IsEmpty() bool {
+	return a == nil || len(a.Rules) == 0
+}
+
+// newArchitecture returns the architecture given by the 'layer', 'allow' and 'deny' directives,
+// or an error if a rule refers to an unknown layer.
+func newArchitecture(directives []*Directive, modDir string) (*Architecture, error) {
+	a := &Architecture{}
+	layers := make(map[string]*Layer)
+	for _, d := range directives {
+		if d.Name != DirectiveLayer {
+			continue
+		}
+		name := d.Args[0]
+		l, ok := layers[name]
+		if !ok {
+			l = &Layer{Name: name}
+			layers[name] = l
+			a.Layers = append(a.Layers, l)
+		}
+		for _, arg := range d.Args[1:] {
+			if strings.HasPrefix(arg, ".") || filepath.IsAbs(arg) {
+				l.Dirs = append(l.Dirs, NewDirPattern(modDir, arg))
+			} else {
+				l.Packages = append(l.Packages, arg)
+			}
+		}
+	}
+
+	for _, d := range directives {
+		if d.Name != DirectiveAllow && d.Name != DirectiveDeny {
+			continue
+		}
+		for _, name := range d.Args {
+			if _, ok := layers[name]; !ok {
+				return nil, &DirectiveError{Pos: d.Pos, Err: fmt.Errorf("%s: unknown layer %s, declare it by `%s %s ./path/to/dir/...`", d.Written(), name, DirectiveLayer, name)}
+			}
+		}
+		a.Rules = append(a.Rules, &LayerRule{From: d.Args[0], To: d.Args[1:], Allow: d.Name == DirectiveAllow, Pos: d.Pos})
+	}
+
+	return a, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,188 @@
+package parser
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// markerPkgPath is the import path of the package providing markers like `blueprint.Bind`.
+const markerPkgPath = "github.com/yuemori/blueprinter/blueprint"
+
+// A Binding is an interface binding declared with `var _ = blueprint.Bind[Iface](NewImpl)`.
+type Binding struct {
+	iface *Iface
+	fn    *Func
+	pos   token.Position
+	// pkg is the import path of the package declaring the binding.
+	pkg string
+
+	target types.Type
+	ctor   *types.Func
+}
+
+// Iface returns the interface to be bound.
+func (b *Binding) Iface() *Iface {
+	return b.iface
+}
+
+// Func returns the constructor bound to the interface.
+func (b *Binding) Func() *Func {
+	return b.fn
+}
+
+// Pos returns the position of `blueprint.Bind`.
+func (b *Binding) Pos() token.Position {
+	return b.pos
+}
+
+// A ProviderError is an error of a provider given by `blueprint.Bind` or a provider set of google/wire.
+type ProviderError struct {
+	Pos token.Position
+	Err error
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Err)
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// Bindings returns all bindings declared with `blueprint.Bind` in the scanned packages.
+func (c *ObjectCache) Bindings() []*Binding {
+	return c.bindings
+}
+
+// collectBindings collects the bindings declared as package level variables in 'file'.
+func (c *ObjectCache) collectBindings(pkg *packages.Package, file *ast.File) []error {
+	errs := make([]error, 0)
+	for _, decl := range file.Decls {
+		g, ok := decl.(*ast.GenDecl)
+		if !ok || g.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range g.Specs {
+			vs, ok := spec.(*ast.ValueSpec)
+			if !ok {
+				continue
+			}
+			for _, value := range vs.Values {
+				call, ok := unparen(value).(*ast.CallExpr)
+				if !ok || !IsMarker(pkg.TypesInfo, call.Fun, "Bind") {
+					continue
+				}
+				pos := pkg.Fset.Position(call.Pos())
+				b, err := newBinding(pkg, call, pos)
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				c.bindings = append(c.bindings, b)
+			}
+		}
+	}
+	return errs
+}
+
+// IsMarker returns true if 'fun' refers the marker function 'name', like `blueprint.Bind[T]`.
+func IsMarker(info *types.Info, fun ast.Expr, name string) bool {
+	fn, ok := info.Uses[markerIdent(fun)].(*types.Func)
+	return ok && fn.Pkg() != nil && fn.Pkg().Path() == markerPkgPath && fn.Name() == name
+}
+
+// markerIdent returns the identifier of the function referred by 'fun', like `Bind` in `blueprint.Bind[T]`
+// or `NewRepo` in `NewRepo[T]`.
+func markerIdent(fun ast.Expr) *ast.Ident {
+	switch e := unparen(fun).(type) {
+	case *ast.IndexExpr:
+		return identOf(e.X)
+	case *ast.IndexListExpr:
+		return identOf(e.X)
+	default:
+		return identOf(e)
+	}
+}
+
+// newBinding reads `blueprint.Bind[Iface](NewImpl)`.
+// The interface and the constructor are linked to the ObjectCache later by linkBindings.
+func newBinding(pkg *packages.Package, call *ast.CallExpr, pos token.Position) (*Binding, error) {
+	target, fn, err := ReadBind(pkg.TypesInfo, call)
+	if err != nil {
+		return nil, &ProviderError{Pos: pos, Err: err}
+	}
+	return &Binding{target: target, ctor: fn, pos: pos, pkg: pkg.PkgPath}, nil
+}
+
+// ReadBind returns the type argument and the constructor given to `blueprint.Bind[T](constructor)` called by 'call'.
+func ReadBind(info *types.Info, call *ast.CallExpr) (types.Type, *types.Func, error) {
+	inst, ok := info.Instances[markerIdent(call.Fun)]
+	if !ok || inst.TypeArgs.Len() != 1 || len(call.Args) != 1 {
+		return nil, nil, fmt.Errorf("blueprint.Bind must be called like blueprint.Bind[Iface](NewImpl)")
+	}
+
+	var ctor types.Object
+	if arg := markerIdent(call.Args[0]); arg != nil {
+		ctor = info.Uses[arg]
+	}
+	fn, ok := ctor.(*types.Func)
+	if !ok {
+		return nil, nil, fmt.Errorf("the argument of blueprint.Bind must be a function, but: %s", types.ExprString(call.Args[0]))
+	}
+	return inst.TypeArgs.At(0), fn, nil
+}
+
+// identOf returns the identifier referred by 'expr' like `pkg.Name` or `Name`, or nil.
+func identOf(expr ast.Expr) *ast.Ident {
+	switch e := unparen(expr).(type) {
+	case *ast.Ident:
+		return e
+	case *ast.SelectorExpr:
+		return e.Sel
+	default:
+		return nil
+	}
+}
+
+// linkBindings links the interfaces and the constructors of the bindings to the objects in the cache.
+// Constructors declared outside the scanned packages are added to the cache, and the invalid bindings are dropped.
+func (c *ObjectCache) linkBindings() []error {
+	errs := make([]error, 0)
+
+	linked := make([]*Binding, 0, len(c.bindings))
+	for _, b := range c.bindings {
+		named, ok := b.target.(*types.Named)
+		if !ok || !types.IsInterface(named) {
+			errs = append(errs, &ProviderError{Pos: b.pos, Err: fmt.Errorf("%s is not a named interface", types.TypeString(b.target, nil))})
+			continue
+		}
+		if obj, ok := c.Get(named.Obj().Pkg().Path(), named.Obj().Name()); ok {
+			b.iface = &Iface{obj}
+		} else {
+			b.iface, _ = newObject(named.Obj(), nil).Interface()
+		}
+
+		// An unexported function can not be called by the generated code in the other package.
+		if !b.ctor.Exported() {
+			errs = append(errs, &ProviderError{Pos: b.pos, Err: fmt.Errorf("%s is unexported and cannot be called from the container", b.ctor.Name())})
+			continue
+		}
+		obj, ok := c.Get(b.ctor.Pkg().Path(), b.ctor.Name())
+		if !ok {
+			obj = newObject(b.ctor, nil)
+			c.Add(obj)
+		}
+		b.fn, _ = obj.Func()
+		linked = append(linked, b)
+	}
+	c.bindings = linked
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"fmt"
 	"go/types"
 	"strings"
 )
@@ -9,6 +10,16 @@ type Type types.Type
 
 type ObjectCache struct {
 	objects []*Object
+	// packages are all packages loaded including dependencies, keyed by their import paths.
+	packages map[string]*types.Package
+	// wireSets and wireInjectors are read from the declarations using google/wire.
+	wireSets      []*WireSet
+	wireInjectors []*WireInjector
+	// bindings are declared with the markers like `blueprint.Bind`.
+	bindings []*Binding
+	// dirs and modules are the directories of the scanned packages and their modules, keyed by their import paths.
+	dirs    map[string]string
+	modules map[string]string
 }
 
 func Identical(t1, t2 Type) bool {
@@ -21,10 +32,60 @@ func AssignableTo(t1, t2 Type) bool {
 
 func newObjectCache() *ObjectCache {
 	return &ObjectCache{
-		objects: make([]*Object, 0),
+		objects:  make([]*Object, 0),
+		packages: make(map[string]*types.Package),
+		dirs:     make(map[string]string),
+		modules:  make(map[string]string),
 	}
 }
 
+// copy returns a new ObjectCache which shares everything but the objects with 'c'.
+func (c *ObjectCache) copy() *ObjectCache {
+	cache := newObjectCache()
+	cache.packages = c.packages
+	cache.wireSets = c.wireSets
+	cache.wireInjectors = c.wireInjectors
+	cache.bindings = c.bindings
+	cache.dirs = c.dirs
+	cache.modules = c.modules
+	return cache
+}
+
+// addPackage adds 'pkg' and its dependencies to the cache.
+func (c *ObjectCache) addPackage(pkg *types.Package) {
+	if _, ok := c.packages[pkg.Path()]; ok {
+		return
+	}
+	c.packages[pkg.Path()] = pkg
+	for _, imp := range pkg.Imports() {
+		c.addPackage(imp)
+	}
+}
+
+// LookupIface returns the exported interface referred as 'path/to/pkg.Name' from all loaded packages,
+// including dependencies such as the standard library.
+func (c *ObjectCache) LookupIface(qualifiedName string) (*Iface, error) {
+	i := strings.LastIndex(qualifiedName, ".")
+	if i < 0 {
+		return nil, fmt.Errorf("interface must be specified as path/to/package.Name, but: %s", qualifiedName)
+	}
+	path, name := qualifiedName[:i], qualifiedName[i+1:]
+
+	pkg, ok := c.packages[path]
+	if !ok {
+		return nil, fmt.Errorf("package %s is not imported by the scanned packages", path)
+	}
+	obj, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+	if !ok || !obj.Exported() {
+		return nil, fmt.Errorf("%s is not found", qualifiedName)
+	}
+	iface, ok := newObject(obj, nil).Interface()
+	if !ok {
+		return nil, fmt.Errorf("%s is not an interface", qualifiedName)
+	}
+	return iface, nil
+}
+
 func (c *ObjectCache) Get(pkg, name string) (*Object, bool) {
 	for _, obj := range c.objects {
 		if obj.Name() == name && obj.ImportPath() == pkg {
@@ -34,6 +95,18 @@ func (c *ObjectCache) Get(pkg, name string) (*Object, bool) {
 	return nil, false
 }
 
+// ObjectOf returns the object which declares the named type 't' or the type pointed by 't'.
+func (c *ObjectCache) ObjectOf(t Type) (*Object, bool) {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return nil, false
+	}
+	return c.Get(named.Obj().Pkg().Path(), named.Obj().Name())
+}
+
 func IsEmpty(typ Type) bool {
 	switch t := typ.(type) {
 	case *types.Interface:
@@ -54,18 +127,146 @@ func TypeNamePrefixedByImportPath(t Type) string {
 // see: https://go.dev/ref/spec#Qualified_identifiers
 func QualifiedTypeName(t Type) string {
 	return types.TypeString(t, func(pkg *types.Package) string {
-		str := pkg.Path()
-		for _, rep := range []string{".", "/", "-"} {
-			str = strings.Replace(str, rep, "_", -1)
+		return escapeImportPath(pkg.Path())
+	})
+}
+
+// LocalQualifiedTypeName is like QualifiedTypeName, but types declared in the package 'local' are not qualified.
+func LocalQualifiedTypeName(t Type, local string) string {
+	return types.TypeString(t, func(pkg *types.Package) string {
+		if pkg.Path() == local {
+			return ""
 		}
-		return str
+		return escapeImportPath(pkg.Path())
 	})
 }
 
+// LocalQualifiedName is like LocalQualifiedTypeName, but for the package-level object 'obj' like a constant.
+func LocalQualifiedName(obj types.Object, local string) string {
+	if obj.Pkg() == nil || obj.Pkg().Path() == local {
+		return obj.Name()
+	}
+	return escapeImportPath(obj.Pkg().Path()) + "." + obj.Name()
+}
+
+// ImportPathsOfObject returns the imports required to refer the package-level object 'obj' from the package 'local'.
+func ImportPathsOfObject(obj types.Object, local string) []string {
+	if obj.Pkg() == nil || obj.Pkg().Path() == local {
+		return nil
+	}
+	return []string{fmt.Sprintf("%s \"%s\"", escapeImportPath(obj.Pkg().Path()), obj.Pkg().Path())}
+}
+
+// ImportPathsOf returns the imports required to refer 't' from the package 'local'.
+// Each import is formatted like Object.FullImportPath.
+func ImportPathsOf(t Type, local string) []string {
+	paths := make([]string, 0)
+	for _, pkg := range packagesOf(t, map[types.Type]bool{}) {
+		if pkg.Path() == local {
+			continue
+		}
+		paths = append(paths, fmt.Sprintf("%s \"%s\"", escapeImportPath(pkg.Path()), pkg.Path()))
+	}
+	return paths
+}
+
+func packagesOf(t types.Type, seen map[types.Type]bool) []*types.Package {
+	if seen[t] {
+		return nil
+	}
+	seen[t] = true
+
+	switch t := t.(type) {
+	case *types.Named:
+		pkgs := make([]*types.Package, 0)
+		if pkg := t.Obj().Pkg(); pkg != nil {
+			pkgs = append(pkgs, pkg)
+		}
+		args := t.TypeArgs()
+		for i := 0; i < args.Len(); i++ {
+			pkgs = append(pkgs, packagesOf(args.At(i), seen)...)
+		}
+		return pkgs
+	case *types.Pointer:
+		return packagesOf(t.Elem(), seen)
+	case *types.Slice:
+		return packagesOf(t.Elem(), seen)
+	case *types.Array:
+		return packagesOf(t.Elem(), seen)
+	case *types.Chan:
+		return packagesOf(t.Elem(), seen)
+	case *types.Map:
+		return append(packagesOf(t.Key(), seen), packagesOf(t.Elem(), seen)...)
+	case *types.Signature:
+		pkgs := make([]*types.Package, 0)
+		for _, tuple := range []*types.Tuple{t.Params(), t.Results()} {
+			for i := 0; i < tuple.Len(); i++ {
+				pkgs = append(pkgs, packagesOf(tuple.At(i).Type(), seen)...)
+			}
+		}
+		return pkgs
+	default:
+		return nil
+	}
+}
+
+// escapeImportPath replaces '.', '/', '-' in the import path to '_'.
+func escapeImportPath(path string) string {
+	for _, rep := range []string{".", "/", "-"} {
+		path = strings.Replace(path, rep, "_", -1)
+	}
+	return path
+}
+
 func (c *ObjectCache) Add(obj *Object) {
 	c.objects = append(c.objects, obj)
 }
 
+// WithObjects returns a new ObjectCache which contains 'objs' in addition to the objects of the cache.
+func (c *ObjectCache) WithObjects(objs []*Object) *ObjectCache {
+	cache := c.copy()
+	cache.objects = append(append(cache.objects, c.objects...), objs...)
+	return cache
+}
+
+// WithProfile returns a new ObjectCache which contains only the objects available in the profile.
+// A struct whose constructors are all out of the profile is dropped as well,
+// so that it does not make the interfaces it implements ambiguous with the implementations in the profile.
+func (c *ObjectCache) WithProfile(profile string) *ObjectCache {
+	// constructed is true for the types having a constructor in the profile,
+	// and false for the ones having constructors only out of the profile.
+	constructed := make(map[*types.TypeName]bool)
+	for _, obj := range c.objects {
+		fn, ok := obj.Func()
+		if !ok || fn.Results().Len() != 1 {
+			continue
+		}
+		t := fn.Results().At(0).Type()
+		if ptr, ok := t.(*types.Pointer); ok {
+			t = ptr.Elem()
+		}
+		if named, ok := t.(*types.Named); ok {
+			constructed[named.Obj()] = constructed[named.Obj()] || obj.InProfile(profile)
+		}
+	}
+
+	cache := c.copy()
+	for _, obj := range c.objects {
+		if !obj.InProfile(profile) {
+			continue
+		}
+		if tn, ok := obj.object.(*types.TypeName); ok && !obj.IsInjectable() {
+			if _, isStruct := obj.Struct(); isStruct {
+				if in, ok := constructed[tn]; ok && !in {
+					continue
+				}
+			}
+		}
+		cache.Add(obj)
+	}
+	return cache
+}
+
 func (c *ObjectCache) All() []*Object {
 	return c.objects
 }
@@ -74,6 +275,10 @@ func (c *ObjectCache) Implementations(iface *Iface) []Type {
 	binds := make([]Type, 0)
 	for _, obj := range c.objects {
 		if _, ok := obj.Struct(); ok {
+			// Generic types are bound after instantiation.
+			if obj.IsGeneric() {
+				continue
+			}
 			if types.Implements(obj.Type(), iface.Interface()) {
 				binds = append(binds, obj.Type())
 				continue
@@ -97,6 +302,16 @@ func (c *ObjectCache) Ifaces() []*Iface {
 	return ifaces
 }
 
+func (c *ObjectCache) Structs() []*Struct {
+	structs := make([]*Struct, 0)
+	for _, obj := range c.objects {
+		if st, ok := obj.Struct(); ok {
+			structs = append(structs, st)
+		}
+	}
+	return structs
+}
+
 func (c *ObjectCache) Funcs() []*Func {
 	funcs := make([]*Func, 0)
 	for _, obj := range c.objects {
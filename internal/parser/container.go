@@ -0,0 +1,292 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"strings"
+
+	"github.com/yuemori/blueprinter/internal/logger"
+	"golang.org/x/tools/go/packages"
+)
+
+// A DirPattern is a directory pattern like './internal/...'.
+// It matches the directory and its subdirectories if it ends with '/...', and otherwise the directory only.
+type DirPattern struct {
+	Dir       string
+	Recursive bool
+}
+
+// NewDirPattern returns a DirPattern for 'pattern', which is relative to 'base' unless it is absolute.
+func NewDirPattern(base, pattern string) DirPattern {
+	p := DirPattern{}
+	if pattern == "..." || strings.HasSuffix(pattern, "/...") {
+		p.Recursive = true
+		pattern = strings.TrimSuffix(strings.TrimSuffix(pattern, "..."), "/")
+	}
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(base, pattern)
+	}
+	p.Dir = filepath.Clean(pattern)
+	return p
+}
+
+// Match returns true if the absolute path 'dir' matches the pattern.
+func (p DirPattern) Match(dir string) bool {
+	if dir == p.Dir {
+		return true
+	}
+	return p.Recursive && strings.HasPrefix(dir, p.Dir+string(filepath.Separator))
+}
+
+// A Container is the container struct with the directives in its doc comment:
+//
+//	// blueprinter:container
+//	// blueprinter:scan ./internal/...
+//	// blueprinter:exclude ./internal/legacy/...
+//	// blueprinter:out container.generated.go
+//	type Container struct {
+//		...
+//	}
+//
+// Paths of 'scan' and 'exclude' are relative to the root of the module, and the path of 'out' is
+// relative to the directory of the container package. A 'scan' directive which is not a relative
+// path is an import path pattern like 'github.com/owner/repo/...'.
+// 'container' marks the struct to be generated by `generate --all`.
+// 'layer', 'allow' and 'deny' declare the Architecture checked on the dependencies of the container.
+type Container struct {
+	// Name is the name of the container struct.
+	Name string
+	// Package is the import path of the package declaring the container struct.
+	Package string
+	// Scans are the directories to be scanned instead of the workdir.
+	Scans []DirPattern
+	// Includes are the import path patterns given by 'scan'.
+	Includes []string
+	// Excludes are the directories not to be scanned.
+	Excludes []DirPattern
+	// Out is the path of the generated code, or an empty string.
+	Out string
+	// Architecture is the layers and the rules between them.
+	Architecture *Architecture
+}
+
+// LoadContainer reads the directives of the container struct 'name' in the package 'pkgPath'.
+// If 'name' is empty, the struct having the directives is looked up from the package.
+// 'ns' is needed since the struct may also have the provider directives.
+// All the errors of the directives are returned at once.
+func LoadContainer(ctx context.Context, dir string, env []string, pkgPath, name string, ns Namespace) (*Container, []error) {
+	cfg := &packages.Config{
+		Context:    ctx,
+		Mode:       packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedModule,
+		Dir:        dir,
+		Env:        env,
+		BuildFlags: []string{"-tags", "skip_blueprinter"},
+	}
+	pkgs, err := packages.Load(cfg, "pattern="+pkgPath)
+	if err != nil {
+		return nil, []error{err}
+	}
+	if len(pkgs) != 1 || len(pkgs[0].Errors) > 0 || len(pkgs[0].GoFiles) == 0 {
+		return nil, []error{fmt.Errorf("package %s is not found", pkgPath)}
+	}
+	pkg := pkgs[0]
+
+	pkgDir := filepath.Dir(pkg.GoFiles[0])
+	modDir := pkgDir
+	if pkg.Module != nil && pkg.Module.Dir != "" {
+		modDir = pkg.Module.Dir
+	}
+
+	candidates := make([]*Container, 0)
+	errs := make([]error, 0)
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			g, ok := decl.(*ast.GenDecl)
+			if !ok || g.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range g.Specs {
+				t, ok := spec.(*ast.TypeSpec)
+				if !ok {
+					continue
+				}
+				if _, ok := t.Type.(*ast.StructType); !ok {
+					continue
+				}
+				if name != "" && t.Name.Name != name {
+					continue
+				}
+				directives, derrs := ParseDirectives(pkg.Fset, DocOf(g, t), ns)
+				if len(derrs) > 0 {
+					errs = append(errs, derrs...)
+					continue
+				}
+				if name == "" && !hasContainerDirective(directives) {
+					continue
+				}
+				c, err := newContainer(pkg.PkgPath, t.Name.Name, directives, modDir, pkgDir)
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				candidates = append(candidates, c)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	switch {
+	case len(candidates) == 1:
+		logger.Debug("Container Found:", candidates[0].Name)
+		return candidates[0], nil
+	case name != "":
+		return nil, []error{fmt.Errorf("%s.%s is not a struct", pkgPath, name)}
+	case len(candidates) == 0:
+		return nil, []error{fmt.Errorf("no struct having `blueprinter:` directives is found in %s", pkgPath)}
+	default:
+		names := make([]string, 0, len(candidates))
+		for _, c := range candidates {
+			names = append(names, c.Name)
+		}
+		return nil, []error{fmt.Errorf("more than one struct having `blueprinter:` directives are found in %s, specify one of them: [%s]", pkgPath, strings.Join(names, ", "))}
+	}
+}
+
+func newContainer(pkgPath, name string, directives []*Directive, modDir, pkgDir string) (*Container, error) {
+	arch, err := newArchitecture(directives, modDir)
+	if err != nil {
+		return nil, err
+	}
+	c := &Container{Name: name, Package: pkgPath, Architecture: arch}
+
+	for _, d := range directives {
+		switch d.Name {
+		case DirectiveScan:
+			for _, arg := range d.Args {
+				if strings.HasPrefix(arg, ".") || filepath.IsAbs(arg) {
+					c.Scans = append(c.Scans, NewDirPattern(modDir, arg))
+				} else {
+					c.Includes = append(c.Includes, arg)
+				}
+			}
+		case DirectiveContainerExclude:
+			for _, arg := range d.Args {
+				c.Excludes = append(c.Excludes, NewDirPattern(modDir, arg))
+			}
+		case DirectiveOut:
+			c.Out = d.Args[0]
+			if !filepath.IsAbs(c.Out) {
+				c.Out = filepath.Join(pkgDir, c.Out)
+			}
+		}
+	}
+
+	return c, nil
+}
+
+// Containers returns the container structs marked as `blueprinter:container` in the scanned packages.
+func (c *ObjectCache) Containers() ([]*Container, error) {
+	containers := make([]*Container, 0)
+	for _, st := range c.Structs() {
+		if !st.has(DirectiveContainer) {
+			continue
+		}
+		pkgDir := c.dirs[st.ImportPath()]
+		modDir := c.modules[st.ImportPath()]
+		if modDir == "" {
+			modDir = pkgDir
+		}
+		container, err := newContainer(st.ImportPath(), st.Name(), st.directives, modDir, pkgDir)
+		if err != nil {
+			return nil, err
+		}
+		containers = append(containers, container)
+	}
+	return containers, nil
+}
+
+// Dir returns the directory of the scanned package 'pkgPath', or an empty string.
+func (c *ObjectCache) Dir(pkgPath string) string {
+	return c.dirs[pkgPath]
+}
+
+// Uncovered returns the import path patterns and the directories given by 'scan' of 'container'
+// which match no package in the cache, so that they can be loaded in addition.
+func (c *ObjectCache) Uncovered(container *Container) ([]string, []string) {
+	includes := make([]string, 0)
+	for _, pattern := range container.Includes {
+		found := false
+		for pkgPath := range c.dirs {
+			found = found || MatchesImportPath(pkgPath, []string{pattern})
+		}
+		if !found {
+			includes = append(includes, pattern)
+		}
+	}
+
+	dirs := make([]string, 0)
+	for _, scan := range container.Scans {
+		found := false
+		for _, dir := range c.dirs {
+			found = found || scan.Match(dir)
+		}
+		if !found {
+			dirs = append(dirs, scan.Dir)
+		}
+	}
+	return includes, dirs
+}
+
+// InScope returns a new ObjectCache which contains only the objects and the bindings in the scope of 'container'.
+// Objects in the container package, in the packages given by 'scan' as import path patterns,
+// and objects whose directories are unknown such as the ones added by provider sets are kept.
+func (c *ObjectCache) InScope(container *Container) *ObjectCache {
+	cfg := &Config{Scans: container.Scans, Excludes: container.Excludes}
+	inScope := func(pkg string) bool {
+		dir, ok := c.dirs[pkg]
+		return !ok || pkg == container.Package || MatchesImportPath(pkg, container.Includes) || cfg.inScope(dir)
+	}
+
+	cache := c.copy()
+	for _, obj := range c.objects {
+		if inScope(obj.ImportPath()) {
+			cache.Add(obj)
+		}
+	}
+	cache.bindings = make([]*Binding, 0, len(c.bindings))
+	for _, b := range c.bindings {
+		if inScope(b.pkg) {
+			cache.bindings = append(cache.bindings, b)
+		}
+	}
+	return cache
+}
+
+// MatchesImportPath returns true if 'path' matches any of the import path patterns like 'github.com/owner/repo/...'.
+func MatchesImportPath(path string, patterns []string) bool {
+	for _, p := range patterns {
+		if p == path {
+			return true
+		}
+		if strings.HasSuffix(p, "/...") && (path == strings.TrimSuffix(p, "/...") || strings.HasPrefix(path, strings.TrimSuffix(p, "..."))) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasContainerDirective returns true if 'directives' contain any `blueprinter:` directive.
+func hasContainerDirective(directives []*Directive) bool {
+	for _, d := range directives {
+		if strings.HasPrefix(d.Name, ContainerPrefix) {
+			return true
+		}
+	}
+	return false
+}
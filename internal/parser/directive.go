@@ -0,0 +1,359 @@
+package parser
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Names of the directives written in doc comments like `// provider:resolve FuncName`.
+const (
+	DirectiveMustResolve = "provider:must_resolve"
+	DirectiveInclude     = "provider:include"
+	DirectiveResolve     = "provider:resolve"
+	DirectiveExclude     = "provider:exclude"
+	DirectiveInject      = "provider:inject"
+	DirectiveDecorate    = "provider:decorate"
+	DirectiveProxy       = "provider:proxy"
+	DirectivePrimary     = "provider:primary"
+	DirectiveProfile     = "provider:profile"
+	DirectiveSwitch      = "provider:switch"
+	DirectiveCase        = "provider:case"
+	DirectiveOptional    = "provider:optional"
+	DirectiveBind        = "provider:bind"
+	DirectiveRoot        = "provider:root"
+	DirectiveStrict      = "provider:strict"
+
+	DirectiveContainer        = "blueprinter:container"
+	DirectiveScan             = "blueprinter:scan"
+	DirectiveContainerExclude = "blueprinter:exclude"
+	DirectiveOut              = "blueprinter:out"
+	DirectiveLayer            = "blueprinter:layer"
+	DirectiveAllow            = "blueprinter:allow"
+	DirectiveDeny             = "blueprinter:deny"
+	DirectiveRoots            = "blueprinter:roots"
+	DirectiveWire             = "blueprinter:wire"
+)
+
+// LegacyPrefix is the prefix of the provider directives, which is used unless another prefix is configured.
+const LegacyPrefix = "provider:"
+
+// ContainerPrefix is the prefix of the directives of the container struct, which is not configurable.
+const ContainerPrefix = "blueprinter:"
+
+// A Namespace is the prefixes of the provider directives.
+// Directives are identified by their canonical names like DirectiveResolve regardless of the prefix written in comments,
+// so `// blueprinter:resolve FuncName` is DirectiveResolve if Prefix is 'blueprinter:'.
+type Namespace struct {
+	// Prefix is the prefix of the provider directives like 'blueprinter:'. If empty, LegacyPrefix is used.
+	Prefix string
+	// Legacy accepts LegacyPrefix as well as Prefix, while annotations are migrated to Prefix.
+	Legacy bool
+}
+
+// Validate returns an error if Prefix is not like 'blueprinter:'.
+func (n Namespace) Validate() error {
+	if n.Prefix == "" {
+		return nil
+	}
+	if !strings.HasSuffix(n.Prefix, ":") || strings.ContainsAny(n.Prefix, " \t") || strings.Count(n.Prefix, ":") != 1 {
+		return fmt.Errorf("annotation prefix must be like 'blueprinter:', but: %q", n.Prefix)
+	}
+	return nil
+}
+
+// prefixes returns the prefixes of the provider directives accepted in the namespace.
+func (n Namespace) prefixes() []string {
+	if n.Prefix == "" || n.Prefix == LegacyPrefix {
+		return []string{LegacyPrefix}
+	}
+	if n.Legacy {
+		return []string{n.Prefix, LegacyPrefix}
+	}
+	return []string{n.Prefix}
+}
+
+// Format returns the directive 'name' written in the namespace, like 'blueprinter:resolve' for DirectiveResolve.
+func (n Namespace) Format(name string) string {
+	if n.Prefix == "" || !strings.HasPrefix(name, LegacyPrefix) {
+		return name
+	}
+	return n.Prefix + strings.TrimPrefix(name, LegacyPrefix)
+}
+
+// lookup returns the canonical name and the prefix of the directive written as 'name', or false if 'name' is not a known directive,
+// like prose starting with the prefix.
+// 'hasArgs' distinguishes `blueprinter:exclude ./path/...` of the container struct from `blueprinter:exclude`
+// of the provider directives if Prefix is 'blueprinter:'.
+func (n Namespace) lookup(name string, hasArgs bool) (string, string, bool) {
+	if _, ok := directiveSpecs[name]; ok && strings.HasPrefix(name, ContainerPrefix) {
+		if name != DirectiveContainerExclude || hasArgs || n.Prefix != ContainerPrefix {
+			return name, ContainerPrefix, true
+		}
+	}
+	for _, prefix := range n.prefixes() {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		canonical := LegacyPrefix + strings.TrimPrefix(name, prefix)
+		if _, ok := directiveSpecs[canonical]; ok {
+			return canonical, prefix, true
+		}
+	}
+	return "", "", false
+}
+
+// A directiveSpec describes the arguments of a directive.
+type directiveSpec struct {
+	// usage describes the arguments like "[path/to/package] FuncName".
+	usage string
+	// min and max are the numbers of the arguments. max < 0 means unlimited.
+	min, max int
+	// list is true if the arguments may be separated by commas as well as spaces.
+	list bool
+	// validate checks the types of the arguments, if needed.
+	validate func(args []string) error
+}
+
+var directiveSpecs = map[string]*directiveSpec{
+	DirectiveMustResolve: {min: 0, max: 0},
+	DirectiveInclude:     {min: 0, max: 0},
+	DirectiveResolve:     {usage: "[path/to/package] FuncName", min: 1, max: 2},
+	DirectiveExclude:     {min: 0, max: 0},
+	DirectiveInject:      {min: 0, max: 0},
+	DirectiveDecorate:    {usage: "[order]", min: 0, max: 1, validate: validateInts},
+	DirectiveProxy:       {min: 0, max: 0},
+	DirectivePrimary:     {min: 0, max: 0},
+	DirectiveProfile:     {usage: "profile...", min: 1, max: -1, list: true, validate: validateProfiles},
+	DirectiveSwitch:      {usage: "selector", min: 1, max: 1},
+	DirectiveCase:        {usage: "value [path/to/package] FuncName", min: 2, max: 3},
+	DirectiveOptional:    {usage: "param...", min: 1, max: -1, list: true},
+	DirectiveBind:        {usage: "path/to/package.Iface...", min: 1, max: -1, list: true},
+	DirectiveRoot:        {min: 0, max: 0},
+	DirectiveStrict:      {min: 0, max: 0},
+
+	DirectiveContainer:        {min: 0, max: 0},
+	DirectiveScan:             {usage: "./path/to/dir/... or path/to/package/...", min: 1, max: -1},
+	DirectiveContainerExclude: {usage: "./path/to/dir/...", min: 1, max: -1},
+	DirectiveOut:              {usage: "path/to/file.go", min: 1, max: 1},
+	DirectiveLayer:            {usage: "name ./path/to/dir/... or path/to/package/...", min: 2, max: -1},
+	DirectiveAllow:            {usage: "layer layer...", min: 2, max: -1},
+	DirectiveDeny:             {usage: "layer layer...", min: 2, max: -1},
+	DirectiveRoots:            {usage: "path/to/package.FuncName...", min: 1, max: -1, list: true},
+	DirectiveWire:             {usage: "path/to/package.ProviderSet...", min: 1, max: -1, list: true},
+}
+
+// A Directive is a line of a doc comment like `// provider:resolve path/to/package FuncName`.
+type Directive struct {
+	// Name is the canonical name of the directive like DirectiveResolve.
+	Name string
+	// Prefix is the prefix written in the comment, which may differ from the one of Name.
+	Prefix string
+	Args   []string
+	Pos    token.Position
+}
+
+// Written returns the name of the directive as written in the comment.
+func (d *Directive) Written() string {
+	if !d.IsProvider() {
+		return d.Name
+	}
+	return d.Prefix + strings.TrimPrefix(d.Name, LegacyPrefix)
+}
+
+// IsProvider returns true if the directive is a provider directive, not a directive of the container struct.
+func (d *Directive) IsProvider() bool {
+	return strings.HasPrefix(d.Name, LegacyPrefix)
+}
+
+func (d *Directive) String() string {
+	return strings.Join(append([]string{d.Written()}, d.Args...), " ")
+}
+
+func validateInts(args []string) error {
+	for _, arg := range args {
+		if _, err := strconv.Atoi(arg); err != nil {
+			return fmt.Errorf("%q is not an integer", arg)
+		}
+	}
+	return nil
+}
+
+// A DirectiveError is an error of an unknown or malformed directive.
+type DirectiveError struct {
+	Pos token.Position
+	Err error
+}
+
+func (e *DirectiveError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Err)
+}
+
+func (e *DirectiveError) Unwrap() error {
+	return e.Err
+}
+
+// ParseDirectives returns the directives in 'doc', which may be line comments or block comments.
+// A malformed directive or a typo of a directive is a *DirectiveError with its position.
+func ParseDirectives(fset *token.FileSet, doc *ast.CommentGroup, ns Namespace) ([]*Directive, []error) {
+	if doc == nil {
+		return nil, nil
+	}
+
+	directives := make([]*Directive, 0)
+	errs := make([]error, 0)
+	for _, comment := range doc.List {
+		pos := fset.Position(comment.Pos())
+		for i, line := range commentLines(comment.Text) {
+			d, err := parseDirective(line, ns)
+			if err != nil {
+				errs = append(errs, &DirectiveError{Pos: pos, Err: err})
+			}
+			if d != nil {
+				d.Pos = pos
+				directives = append(directives, d)
+			}
+			// Lines in a block comment are reported at their own lines.
+			if i == 0 {
+				pos.Column = 0
+			}
+			pos.Line++
+		}
+	}
+
+	if len(errs) == 0 {
+		errs = nil
+	}
+	return directives, errs
+}
+
+// commentLines returns the lines of 'text' without the comment markers '//', '/*', '*/' and leading '*'.
+func commentLines(text string) []string {
+	if strings.HasPrefix(text, "//") {
+		return []string{text[2:]}
+	}
+	text = strings.TrimSuffix(strings.TrimPrefix(text, "/*"), "*/")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		// The leading '*' of lines in a block comment is decoration.
+		if i > 0 {
+			line = strings.TrimPrefix(line, "*")
+		}
+		lines[i] = line
+	}
+	return lines
+}
+
+// parseDirective returns the directive in 'line', or nil if 'line' is not a directive.
+// The position of the directive is set by the caller.
+func parseDirective(line string, ns Namespace) (*Directive, error) {
+	line = strings.TrimSpace(line)
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	written := fields[0]
+
+	name, prefix, ok := ns.lookup(written, len(fields) > 1)
+	if !ok {
+		// Prose like `provider: this is the default implementation.` is not a directive,
+		// so an unknown name is reported only if it looks like a typo of a directive, like `provder:exclude`.
+		if strings.Contains(written, ":") {
+			if suggestion := suggestDirective(written, ns); suggestion != "" {
+				return nil, fmt.Errorf("unknown directive %s, did you mean %s?", written, suggestion)
+			}
+		}
+		return nil, nil
+	}
+	spec := directiveSpecs[name]
+
+	args := fields[1:]
+	if spec.list {
+		args = strings.FieldsFunc(strings.Join(args, " "), func(r rune) bool {
+			return r == ',' || r == ' '
+		})
+	}
+	if len(args) < spec.min || (spec.max >= 0 && len(args) > spec.max) {
+		if spec.usage == "" {
+			return nil, fmt.Errorf("%s takes no arguments, but: %s", written, line)
+		}
+		return nil, fmt.Errorf("%s format must be `%s %s`, but: %s", written, written, spec.usage, line)
+	}
+	if spec.validate != nil {
+		if err := spec.validate(args); err != nil {
+			return nil, fmt.Errorf("%s format must be `%s %s`: %w", written, written, spec.usage, err)
+		}
+	}
+
+	return &Directive{Name: name, Prefix: prefix, Args: args}, nil
+}
+
+// suggestDirective returns the known directive in 'ns' closest to 'name' if it looks like a typo, or an empty string.
+func suggestDirective(name string, ns Namespace) string {
+	names := make([]string, 0, len(directiveSpecs))
+	for n := range directiveSpecs {
+		if strings.HasPrefix(n, ContainerPrefix) {
+			names = append(names, n)
+			continue
+		}
+		for _, prefix := range ns.prefixes() {
+			names = append(names, prefix+strings.TrimPrefix(n, LegacyPrefix))
+		}
+	}
+	sort.Strings(names)
+
+	best, bestDistance := "", 3
+	for _, n := range names {
+		if d := levenshtein(name, n); d < bestDistance {
+			best, bestDistance = n, d
+		}
+	}
+	return best
+}
+
+// levenshtein returns the edit distance between 'a' and 'b'.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// DocOf returns the doc comment of the type spec 't' in 'g'.
+// A spec in a grouped declaration like `type ( ... )` has its own doc comment.
+func DocOf(g *ast.GenDecl, t *ast.TypeSpec) *ast.CommentGroup {
+	if g.Lparen.IsValid() {
+		return t.Doc
+	}
+	if t.Doc != nil {
+		return t.Doc
+	}
+	return g.Doc
+}
@@ -0,0 +1,129 @@
+package parser
+
+import (
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDirective(t *testing.T) {
+	legacy := Namespace{}
+	prefixed := Namespace{Prefix: "blueprinter:", Legacy: true}
+
+	tests := []struct {
+		name string
+		ns   Namespace
+		line string
+		// want is nil if 'line' is not a directive.
+		want *Directive
+		// err is a substring of the error, or empty if no error is expected.
+		err string
+	}{
+		{name: "empty", ns: legacy, line: "  ", want: nil},
+		{name: "prose", ns: legacy, line: " NewRepo returns a Repo.", want: nil},
+		{name: "prose with a url", ns: legacy, line: " see https://example.com", want: nil},
+		{name: "prose after a bare prefix", ns: legacy, line: " provider: this is the default implementation.", want: nil},
+		{name: "bare prefix", ns: legacy, line: " provider:", want: nil},
+		{name: "prose like a directive", ns: legacy, line: " provider:default implementation", want: nil},
+		{name: "prose after a bare container prefix", ns: legacy, line: " blueprinter: generates the container", want: nil},
+		{name: "no args", ns: legacy, line: " provider:include", want: &Directive{Name: DirectiveInclude, Prefix: "provider:", Args: []string{}}},
+		{name: "one arg", ns: legacy, line: " provider:resolve NewRepo", want: &Directive{Name: DirectiveResolve, Prefix: "provider:", Args: []string{"NewRepo"}}},
+		{name: "max args", ns: legacy, line: " provider:resolve path/to/repo NewRepo", want: &Directive{Name: DirectiveResolve, Prefix: "provider:", Args: []string{"path/to/repo", "NewRepo"}}},
+		{name: "too few args", ns: legacy, line: " provider:resolve", err: "provider:resolve format must be `provider:resolve [path/to/package] FuncName`"},
+		{name: "too many args", ns: legacy, line: " provider:resolve path/to/repo NewRepo extra", err: "provider:resolve format must be"},
+		{name: "args of no args", ns: legacy, line: " provider:include all", err: "provider:include takes no arguments"},
+		{name: "invalid arg", ns: legacy, line: " provider:decorate first", err: `"first" is not an integer`},
+		{name: "list args", ns: legacy, line: " provider:profile dev, prod,test", want: &Directive{Name: DirectiveProfile, Prefix: "provider:", Args: []string{"dev", "prod", "test"}}},
+		{name: "invalid profile", ns: legacy, line: " provider:profile dev-local", err: `invalid profile "dev-local"`},
+		{name: "typo in name", ns: legacy, line: " provider:resolv NewRepo", err: "unknown directive provider:resolv, did you mean provider:resolve?"},
+		{name: "typo in prefix", ns: legacy, line: " provder:include", err: "unknown directive provder:include, did you mean provider:include?"},
+		{name: "typo in container directive", ns: legacy, line: " blueprinter:scna ./...", err: "did you mean blueprinter:scan?"},
+		{name: "container directive", ns: legacy, line: " blueprinter:scan ./internal/...", want: &Directive{Name: DirectiveScan, Prefix: "blueprinter:", Args: []string{"./internal/..."}}},
+		{name: "wire sets", ns: legacy, line: " blueprinter:wire path/to/a.ProdSet, path/to/b.Set", want: &Directive{Name: DirectiveWire, Prefix: "blueprinter:", Args: []string{"path/to/a.ProdSet", "path/to/b.Set"}}},
+		{name: "configured prefix", ns: prefixed, line: " blueprinter:resolve NewRepo", want: &Directive{Name: DirectiveResolve, Prefix: "blueprinter:", Args: []string{"NewRepo"}}},
+		{name: "legacy prefix", ns: prefixed, line: " provider:resolve NewRepo", want: &Directive{Name: DirectiveResolve, Prefix: "provider:", Args: []string{"NewRepo"}}},
+		{name: "provider exclude", ns: prefixed, line: " blueprinter:exclude", want: &Directive{Name: DirectiveExclude, Prefix: "blueprinter:", Args: []string{}}},
+		{name: "container exclude", ns: prefixed, line: " blueprinter:exclude ./mock/...", want: &Directive{Name: DirectiveContainerExclude, Prefix: "blueprinter:", Args: []string{"./mock/..."}}},
+		{name: "typo of configured prefix", ns: prefixed, line: " blueprinter:resolv NewRepo", err: "did you mean blueprinter:resolve?"},
+		{name: "prose of configured prefix", ns: prefixed, line: " blueprinter: this is the default implementation.", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDirective(tt.line, tt.ns)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("parseDirective(%q) error = %v, want %q", tt.line, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseDirective(%q) unexpected error: %v", tt.line, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseDirective(%q) = %+v, want %+v", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommentLines(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "line comment", text: "// provider:include", want: []string{" provider:include"}},
+		{name: "one line block comment", text: "/* provider:include */", want: []string{"provider:include"}},
+		{
+			name: "block comment",
+			text: "/*\n * provider:resolve NewRepo\n * provider:primary\n */",
+			want: []string{"", " provider:resolve NewRepo", " provider:primary", ""},
+		},
+		{
+			name: "block comment without decoration",
+			text: "/*\nprovider:resolve NewRepo\n\tprovider:primary */",
+			want: []string{"", "provider:resolve NewRepo", "provider:primary"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := commentLines(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("commentLines(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDirectivesInBlockComment(t *testing.T) {
+	src := `package repo
+
+/*
+ * NewRepo returns a Repo.
+ * provider:resolve NewRepo
+ * provider:resolv NewRepo
+ */
+type Repo struct{}
+`
+	fset := token.NewFileSet()
+	file, err := goparser.ParseFile(fset, "repo.go", src, goparser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := file.Decls[0].(*ast.GenDecl)
+
+	directives, errs := ParseDirectives(fset, DocOf(g, g.Specs[0].(*ast.TypeSpec)), Namespace{})
+	if len(directives) != 1 || directives[0].Name != DirectiveResolve || directives[0].Pos.Line != 5 {
+		t.Errorf("directives = %+v, want provider:resolve at line 5", directives)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("errs = %v, want an error of provider:resolv", errs)
+	}
+	if derr, ok := errs[0].(*DirectiveError); !ok || derr.Pos.Line != 6 {
+		t.Errorf("errs[0] = %v, want a *DirectiveError at line 6", errs[0])
+	}
+}
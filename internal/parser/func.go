@@ -24,15 +24,39 @@ func (f *Func) Results() *types.Tuple {
 	return f.signature().Results()
 }
 
+// Signature returns the signature of the function.
+func (f *Func) Signature() *types.Signature {
+	return f.signature()
+}
+
+// IsGeneric returns true if the function has type parameters.
+func (f *Func) IsGeneric() bool {
+	return f.signature().TypeParams().Len() > 0
+}
+
 func (f *Func) IsConstructor() bool {
 	return f.Results().Len() == 1 && f.Params().Len() > 0
 }
 
+// ShouldTryToResolve returns true if the function gets a public method unless the roots are given.
+// A function marked as `provider:root` needs no params, since it is given a public method explicitly.
 func (f *Func) ShouldTryToResolve() bool {
-	return f.Exported() && !f.IsExcluded() && f.IsConstructor()
+	if !f.Exported() || f.IsExcluded() || f.IsDecorator() || f.IsGeneric() {
+		return false
+	}
+	return f.IsConstructor() || (f.IsRoot() && f.Results().Len() == 1)
 }
 
 func (f *Func) IsBindable() bool {
+	return !f.IsGeneric() && f.hasBindableShape()
+}
+
+// IsInstantiable returns true if the function is generic and can be bound after instantiation.
+func (f *Func) IsInstantiable() bool {
+	return f.IsGeneric() && f.Exported() && !f.IsExcluded() && !f.IsDecorator() && f.hasBindableShape()
+}
+
+func (f *Func) hasBindableShape() bool {
 	if f.Results().Len() != 1 {
 		return false
 	}
@@ -3,26 +3,22 @@ package parser
 import (
 	"fmt"
 	"go/ast"
+	"go/token"
 	"go/types"
 	"regexp"
-	"strings"
-)
-
-var (
-	// Match `provider:must_resolve` comment
-	mustResolveRegexp = regexp.MustCompile("provider:must_resolve")
-	// Match `provider:include` comment
-	includeRegexp = regexp.MustCompile("provider:include")
-	// Match `provider:resolve` comment
-	resolveRegexp = regexp.MustCompile("provider:resolve *")
-	// Match `provider:exclude` comment
-	excludeRegexp = regexp.MustCompile("provider:exclude")
+	"strconv"
 )
 
 // A Object is a wrapper of types.Object.
 type Object struct {
 	object  types.Object
 	comment *ast.CommentGroup
+	// directives are parsed from the comment by NewAnnotatedObject.
+	directives []*Directive
+	// fset is the file set of the package declaring the object, or nil if unknown.
+	fset *token.FileSet
+	// strict is true if the package declaring the object is in the strict mode.
+	strict bool
 }
 
 func newObject(object types.Object, comment *ast.CommentGroup) *Object {
@@ -32,11 +28,58 @@ func newObject(object types.Object, comment *ast.CommentGroup) *Object {
 	}
 }
 
+// NewAnnotatedObject returns an Object with the directives parsed from 'comment'.
+// Objects are usually built by Parse, and this is also used to check annotations package by package.
+func NewAnnotatedObject(fset *token.FileSet, object types.Object, comment *ast.CommentGroup, ns Namespace) (*Object, []error) {
+	directives, errs := ParseDirectives(fset, comment, ns)
+	o := newObject(object, comment)
+	o.directives = directives
+	o.fset = fset
+	errs = append(errs, o.validate()...)
+	return o, errs
+}
+
+// validate returns the errors of the directives which do not match the declaration of the object,
+// like `provider:optional` naming no param of the function.
+func (o *Object) validate() []error {
+	sig, ok := o.object.Type().(*types.Signature)
+	if !ok {
+		return nil
+	}
+
+	errs := make([]error, 0)
+	for _, d := range o.directivesOf(DirectiveOptional) {
+		for _, name := range d.Args {
+			found := false
+			for i := 0; i < sig.Params().Len(); i++ {
+				found = found || sig.Params().At(i).Name() == name
+			}
+			if !found {
+				errs = append(errs, &DirectiveError{Pos: d.Pos, Err: fmt.Errorf("%s: %s has no param named %s", d.Written(), o.Name(), name)})
+			}
+		}
+	}
+	return errs
+}
+
 // Name returns the name of the object.
 func (o *Object) Name() string {
 	return o.object.Name()
 }
 
+// Position returns the position of the declaration of the object, which is invalid if unknown.
+func (o *Object) Position() token.Position {
+	return o.position(o.object.Pos())
+}
+
+// position returns the position of 'pos' in the package of the object.
+func (o *Object) position(pos token.Pos) token.Position {
+	if o.fset == nil {
+		return token.Position{}
+	}
+	return o.fset.Position(pos)
+}
+
 // Pkg returns the package name of the object.
 func (o *Object) Pkg() string {
 	return o.object.Pkg().Name()
@@ -45,13 +88,7 @@ func (o *Object) Pkg() string {
 // FullPkg returns a string like: 'github_com_owner_repo_pkg'
 // This string generated from o.ImportPath() by replacing '.', '/', '-' to '_'.
 func (o *Object) FullPkg() string {
-	str := o.ImportPath()
-
-	for _, rep := range []string{".", "/", "-"} {
-		str = strings.Replace(str, rep, "_", -1)
-	}
-
-	return str
+	return escapeImportPath(o.ImportPath())
 }
 
 // ImportPath returns the import path of the object.
@@ -96,6 +133,12 @@ func (o *Object) Type() types.Type {
 	return o.object.Type()
 }
 
+// IsGeneric returns true if the object is a generic type.
+func (o *Object) IsGeneric() bool {
+	named, ok := o.object.Type().(*types.Named)
+	return ok && named.TypeParams().Len() > 0
+}
+
 // Exported returns true if the object is exported.
 func (o *Object) Exported() bool {
 	return o.object.Exported()
@@ -111,71 +154,242 @@ func (o *Object) Comment() string {
 	return o.comment.Text()
 }
 
+// Directives returns the directives in the comment of the object.
+func (o *Object) Directives() []*Directive {
+	return o.directives
+}
+
+// IsConsidered returns true if the object may be used by the resolver as an interface, an implementation or a constructor.
+// Directives like `provider:exclude` on the other objects have no effect.
+func (o *Object) IsConsidered() bool {
+	if !o.Exported() {
+		return false
+	}
+	if fn, ok := o.Func(); ok {
+		return fn.IsConstructor() || fn.hasBindableShape() || (fn.IsRoot() && fn.Results().Len() == 1)
+	}
+	if _, ok := o.Interface(); ok {
+		return true
+	}
+	_, ok := o.Struct()
+	return ok
+}
+
 // IsExcluded returns true if the object has `provider:exclude` comment.
 func (o *Object) IsExcluded() bool {
-	if o.hasComment(excludeRegexp) {
+	return o.has(DirectiveExclude)
+}
+
+// MustBeResolved returns true if the object has `provider:must_resolve` comment or is in the strict mode.
+func (o *Object) MustBeResolved() bool {
+	return o.has(DirectiveMustResolve) || o.strict
+}
+
+// IsStrict returns true if the object is declared in a package marked as `provider:strict`,
+// or in a package scanned in the strict mode.
+func (o *Object) IsStrict() bool {
+	return o.strict
+}
+
+// IsRoot returns true if the object has `provider:root` comment.
+// If any root is given, only the roots are exposed as public methods of the container.
+func (o *Object) IsRoot() bool {
+	return o.has(DirectiveRoot)
+}
+
+// IsInjectable returns true if the object has `provider:inject` comment.
+func (o *Object) IsInjectable() bool {
+	return o.has(DirectiveInject)
+}
+
+// IsPrimary returns true if the object has `provider:primary` comment.
+func (o *Object) IsPrimary() bool {
+	return o.has(DirectivePrimary)
+}
+
+// Profiles returns the profiles given by `provider:profile dev,test`.
+// If the object has no `provider:profile` comment, this function returns nil.
+func (o *Object) Profiles() []string {
+	if d := o.directive(DirectiveProfile); d != nil {
+		return d.Args
+	}
+	return nil
+}
+
+// profileRegexp matches the profile names, which are used as build tags of the generated code.
+var profileRegexp = regexp.MustCompile(`^[A-Za-z0-9_.]+$`)
+
+// ValidateProfile returns an error if 'profile' cannot be used as a build tag.
+func ValidateProfile(profile string) error {
+	if !profileRegexp.MatchString(profile) {
+		return fmt.Errorf("invalid profile %q: a profile must consist of letters, digits, '_' and '.' to be used as a build tag", profile)
+	}
+	return nil
+}
+
+func validateProfiles(args []string) error {
+	for _, arg := range args {
+		if err := ValidateProfile(arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InProfile returns true if the object is available in the profile.
+// An object without `provider:profile` comment is available in all profiles,
+// and an object with it is not available when no profile is given.
+func (o *Object) InProfile(profile string) bool {
+	profiles := o.Profiles()
+	if profiles == nil {
 		return true
 	}
+	for _, p := range profiles {
+		if p == profile {
+			return true
+		}
+	}
 	return false
 }
 
-// MustBeResolved returns true if the object has `provider:must_resolve` comment.
-func (f *Func) MustBeResolved() bool {
-	return f.hasComment(mustResolveRegexp)
+// BoundIfaces returns the interfaces given by `provider:bind io.Writer net/http.Handler`.
+// The object can be specified on multiple lines, and each interface is formatted as 'path/to/package.Name'.
+func (o *Object) BoundIfaces() []string {
+	ifaces := make([]string, 0)
+	for _, d := range o.directivesOf(DirectiveBind) {
+		ifaces = append(ifaces, d.Args...)
+	}
+	return ifaces
 }
 
-func (o *Object) IsMarkedAsBindable() bool {
-	return o.hasComment(includeRegexp)
+// IsProxied returns true if the object has `provider:proxy` comment.
+func (o *Object) IsProxied() bool {
+	return o.has(DirectiveProxy)
 }
 
-func (o *Object) IsResolve() bool {
-	if o.comment == nil {
-		return false
+// OptionalParams returns the names of the params given by `provider:optional tracer meter`.
+// An optional param is passed the zero value if no derivation is found for it.
+func (f *Func) OptionalParams() []string {
+	params := make([]string, 0)
+	for _, d := range f.directivesOf(DirectiveOptional) {
+		params = append(params, d.Args...)
 	}
+	return params
+}
 
-	for _, comment := range o.comment.List {
-		if resolveRegexp.MatchString(comment.Text) {
-			return true
-		}
+// IsDecorator returns true if the function has `provider:decorate` comment.
+func (f *Func) IsDecorator() bool {
+	return f.has(DirectiveDecorate)
+}
+
+// DecorateOrder returns the order of the decorator.
+// The format of the comment must be `provider:decorate` or `provider:decorate <order>`, and the default order is 0.
+// Decorators with the smaller order wrap the value earlier.
+func (f *Func) DecorateOrder() (int, error) {
+	d := f.directive(DirectiveDecorate)
+	if d == nil || len(d.Args) == 0 {
+		return 0, nil
+	}
+	order, err := strconv.Atoi(d.Args[0])
+	if err != nil {
+		return 0, &DirectiveError{Pos: d.Pos, Err: fmt.Errorf("decorator comment format must be `%s` or `%s <order>`, but: %s", d.Written(), d.Written(), d.String())}
 	}
+	return order, nil
+}
 
-	return false
+// IsMarkedAsBindable returns true if the object has `provider:include` comment.
+// The functions listed in the provider sets of google/wire are bindable only in the containers applying the sets.
+func (o *Object) IsMarkedAsBindable() bool {
+	return o.has(DirectiveInclude)
+}
+
+func (o *Object) IsResolve() bool {
+	return o.has(DirectiveResolve)
 }
 
 // Resolve returns the package path and the function name to be resolved.
 // The format of the comment must be `provider:resolve path/to/package FuncName` or `provider:resolve FuncName`.
-// If the object has no `provider:resolve` comment, this function returns false.
+// If the object has no `provider:resolve` comment, this function returns empty strings.
 func (o *Object) ResolvedPkgAndFuncName() (string, string, error) {
-	if o.comment == nil {
+	d := o.directive(DirectiveResolve)
+	if d == nil {
 		return "", "", nil
 	}
+	if len(d.Args) == 1 {
+		return o.ImportPath(), d.Args[0], nil
+	}
+	return d.Args[0], d.Args[1], nil
+}
 
-	for _, comment := range o.comment.List {
-		if resolveRegexp.MatchString(comment.Text) {
-			path := strings.TrimSpace(strings.TrimPrefix(comment.Text, "// provider:resolve"))
-			paths := strings.Split(path, " ")
-			if len(paths) == 1 {
-				return o.ImportPath(), paths[0], nil
-			}
-			if len(paths) == 2 {
-				return paths[0], paths[1], nil
-			}
+// A SwitchCase is a candidate constructor given by `provider:case`.
+type SwitchCase struct {
+	// Value is a Go literal, an identifier or a qualified constant compared with the selector, like: '"redis"' or 'config.ModeProd'.
+	Value    string
+	Pkg      string
+	FuncName string
+	// Pos is the position of `provider:case`.
+	Pos token.Position
+}
+
+// IsSwitch returns true if the object has `provider:switch` comment.
+func (o *Object) IsSwitch() bool {
+	return o.has(DirectiveSwitch)
+}
 
-			return "", "", fmt.Errorf("resolver comment format must be `provider:resolve path/to/package FuncName` or `provider:resolve FuncName`, but: %s", comment.Text)
+// Switch returns the selector and the candidate constructors to be switched at runtime.
+// The format of the comments must be `provider:switch selector` followed by
+// `provider:case value path/to/package FuncName` or `provider:case value FuncName` for each candidate.
+// If the object has no `provider:switch` comment, this function returns an empty selector.
+func (o *Object) Switch() (string, []*SwitchCase, error) {
+	d := o.directive(DirectiveSwitch)
+	if d == nil {
+		return "", nil, nil
+	}
+
+	cases := make([]*SwitchCase, 0)
+	for _, c := range o.directivesOf(DirectiveCase) {
+		if len(c.Args) == 2 {
+			cases = append(cases, &SwitchCase{Value: c.Args[0], Pkg: o.ImportPath(), FuncName: c.Args[1], Pos: c.Pos})
+		} else {
+			cases = append(cases, &SwitchCase{Value: c.Args[0], Pkg: c.Args[1], FuncName: c.Args[2], Pos: c.Pos})
 		}
 	}
 
-	return "", "", nil
+	if len(cases) == 0 {
+		return "", nil, &DirectiveError{Pos: d.Pos, Err: fmt.Errorf("%s has `%s`, but no `%scase` is given", o.String(), d.Written(), d.Prefix)}
+	}
+	return d.Args[0], cases, nil
 }
 
-func (o *Object) hasComment(r *regexp.Regexp) bool {
-	if o.comment == nil {
-		return false
+// SwitchPos returns the position of `provider:switch` comment, which is invalid if the object has none.
+func (o *Object) SwitchPos() token.Position {
+	if d := o.directive(DirectiveSwitch); d != nil {
+		return d.Pos
 	}
-	for _, comment := range o.comment.List {
-		if r.MatchString(comment.Text) {
-			return true
+	return token.Position{}
+}
+
+// directive returns the first directive named 'name', or nil.
+func (o *Object) directive(name string) *Directive {
+	for _, d := range o.directives {
+		if d.Name == name {
+			return d
 		}
 	}
-	return false
+	return nil
+}
+
+// directivesOf returns all directives named 'name'.
+func (o *Object) directivesOf(name string) []*Directive {
+	directives := make([]*Directive, 0)
+	for _, d := range o.directives {
+		if d.Name == name {
+			directives = append(directives, d)
+		}
+	}
+	return directives
+}
+
+func (o *Object) has(name string) bool {
+	return o.directive(name) != nil
 }
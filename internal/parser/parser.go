@@ -6,28 +6,147 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 
 	"github.com/yuemori/blueprinter/internal/logger"
 	"golang.org/x/tools/go/packages"
 )
 
+// A Config is a configuration for Parse.
+type Config struct {
+	// Dir is the directory where packages are loaded. go.mod and go.work are looked up from it.
+	Dir string
+	Env []string
+	// Roots are the directories to be scanned. If empty, Dir is scanned.
+	Roots   []string
+	Globs   []string
+	Ignores []string
+	// Includes are import path patterns like 'github.com/owner/repo/...' loaded in addition to Roots,
+	// which are resolved through the module graph of Dir and the module cache.
+	Includes []string
+	// Scans are the directories to be scanned instead of Roots. Excludes are the directories not to be scanned.
+	Scans    []DirPattern
+	Excludes []DirPattern
+	// BuildTags are passed to the build in addition to 'skip_blueprinter', like 'wireinject'.
+	BuildTags []string
+	// Namespace is the prefixes of the provider directives.
+	Namespace Namespace
+	// IgnoreDirectiveErrors skips unknown or malformed directives and invalid `blueprint.Bind` instead of failing,
+	// for the callers reporting them by themselves like the analyzer.
+	IgnoreDirectiveErrors bool
+	// Strict are the patterns of the packages whose exported constructors must be resolved,
+	// like the packages marked as `provider:strict` in their package comments.
+	// A pattern is an import path pattern like 'github.com/owner/repo/...', or a directory like './internal/...' relative to Dir.
+	Strict []string
+}
+
+// isStrict returns true if the package 'pkgPath' in the directory 'dir' matches any pattern of Strict.
+func (cfg *Config) isStrict(pkgPath, dir string) bool {
+	for _, p := range cfg.Strict {
+		if !strings.HasPrefix(p, ".") && !filepath.IsAbs(p) {
+			if MatchesImportPath(pkgPath, []string{p}) {
+				return true
+			}
+			continue
+		}
+		base, err := filepath.Abs(cfg.Dir)
+		if err == nil && dir != "" && NewDirPattern(base, p).Match(dir) {
+			return true
+		}
+	}
+	return false
+}
+
 // Parse is a wrapper of packages.Load.
-func Parse(ctx context.Context, dir string, env, globs, ignores []string) (*ObjectCache, []error) {
-	patterns, err := collectPackagePatterns(dir, globs, ignores)
-	if err != nil {
-		return nil, err
+func Parse(ctx context.Context, cfg *Config) (*ObjectCache, []error) {
+	roots := cfg.Roots
+	if len(cfg.Scans) > 0 {
+		roots = make([]string, 0, len(cfg.Scans))
+		for _, scan := range cfg.Scans {
+			roots = append(roots, scan.Dir)
+		}
+	}
+	if len(roots) == 0 {
+		roots = []string{cfg.Dir}
 	}
 
-	pkgs, errs := load(ctx, dir, env, patterns)
+	patterns := make([]string, 0)
+	found := make(map[string]bool)
+	for _, root := range roots {
+		dirs, err := collectPackagePatterns(root, cfg.Globs, cfg.Ignores)
+		if err != nil {
+			return nil, err
+		}
+		for _, dir := range dirs {
+			if !cfg.inScope(dir) {
+				logger.Debug("Skip(out of scope):", dir)
+				continue
+			}
+			if !found[dir] {
+				found[dir] = true
+				patterns = append(patterns, dir)
+			}
+		}
+	}
+
+	logger.Debug("Includes:", cfg.Includes)
+	patterns = append(patterns, cfg.Includes...)
+
+	pkgs, errs := load(ctx, cfg.Dir, cfg.Env, cfg.BuildTags, patterns)
 	if len(errs) != 0 {
 		return nil, errs
 	}
 
-	return buildCache(pkgs), nil
+	return buildCache(pkgs, cfg)
+}
+
+// inScope returns true if the absolute path 'dir' matches Scans, if any, and does not match Excludes.
+func (cfg *Config) inScope(dir string) bool {
+	for _, exclude := range cfg.Excludes {
+		if exclude.Match(dir) {
+			return false
+		}
+	}
+	if len(cfg.Scans) == 0 {
+		return true
+	}
+	for _, scan := range cfg.Scans {
+		if scan.Match(dir) {
+			return true
+		}
+	}
+	return false
 }
 
 func collectPackagePatterns(dir string, globs, ignores []string) ([]string, []error) {
+	files, errs := collectGoFiles(dir, globs, ignores)
+	if errs != nil {
+		return nil, errs
+	}
+
 	dirsFound := make(map[string]bool)
+	patterns := make([]string, 0)
+	for _, path := range files {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, []error{err}
+		}
+
+		dir := filepath.Dir(absPath)
+
+		if _, found := dirsFound[dir]; !found {
+			logger.Debug("Go Directory Found:", dir)
+			dirsFound[dir] = true
+			patterns = append(patterns, dir)
+		}
+	}
+
+	return patterns, nil
+}
+
+// collectGoFiles returns the paths of the Go files under 'dir' which match 'globs' and do not match 'ignores'.
+func collectGoFiles(dir string, globs, ignores []string) ([]string, []error) {
+	files := make([]string, 0)
 
 	logger.Debug("Globs:", globs)
 	logger.Debug("Ignores:", ignores)
@@ -37,8 +156,12 @@ func collectPackagePatterns(dir string, globs, ignores []string) ([]string, []er
 			return err
 		}
 
-		if _, ok := dirsFound[path]; ok {
-			return nil
+		// Skip nested modules, which are scanned as roots of their own if they are in the workspace.
+		if info.IsDir() && path != dir {
+			if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
+				logger.Debug("Skip(nested module):", path)
+				return filepath.SkipDir
+			}
 		}
 
 		if len(ignores) != 0 {
@@ -68,18 +191,8 @@ func collectPackagePatterns(dir string, globs, ignores []string) ([]string, []er
 		}
 
 		// TODO: Skip source code files that are specified with the //go:build !skip_blueprinter directive
-		if filepath.Ext(path) == ".go" {
-			absPath, err := filepath.Abs(path)
-			if err != nil {
-				return err
-			}
-
-			dir := filepath.Dir(absPath)
-
-			if _, found := dirsFound[dir]; !found {
-				logger.Debug("Go Directory Found:", dir)
-				dirsFound[dir] = true
-			}
+		if !info.IsDir() && filepath.Ext(path) == ".go" {
+			files = append(files, path)
 		}
 
 		return nil
@@ -88,22 +201,17 @@ func collectPackagePatterns(dir string, globs, ignores []string) ([]string, []er
 		return nil, []error{err}
 	}
 
-	patterns := make([]string, 0, len(dirsFound))
-	for dir := range dirsFound {
-		patterns = append(patterns, dir)
-	}
-
-	return patterns, nil
+	return files, nil
 }
 
 // see: https://github.com/google/wire/blob/523d8fbe880bb310a188d472bccc0cef939c45b8/internal/wire/parse.go#L352
-func load(ctx context.Context, wd string, env, patterns []string) ([]*packages.Package, []error) {
+func load(ctx context.Context, wd string, env, tags, patterns []string) ([]*packages.Package, []error) {
 	cfg := &packages.Config{
 		Context:    ctx,
-		Mode:       packages.LoadAllSyntax,
+		Mode:       packages.LoadAllSyntax | packages.NeedModule,
 		Dir:        wd,
 		Env:        env,
-		BuildFlags: []string{"-tags", "skip_blueprinter"},
+		BuildFlags: []string{"-tags", strings.Join(append([]string{"skip_blueprinter"}, tags...), ",")},
 	}
 	escaped := make([]string, len(patterns))
 	for i := range patterns {
@@ -126,22 +234,56 @@ func load(ctx context.Context, wd string, env, patterns []string) ([]*packages.P
 	return pkgs, nil
 }
 
-func buildCache(pkgs []*packages.Package) *ObjectCache {
+func buildCache(pkgs []*packages.Package, cfg *Config) (*ObjectCache, []error) {
 	cache := newObjectCache()
+	errs := make([]error, 0)
 
 	for _, pkg := range pkgs {
+		cache.addPackage(pkg.Types)
+		if len(pkg.GoFiles) > 0 {
+			cache.dirs[pkg.PkgPath] = filepath.Dir(pkg.GoFiles[0])
+		}
+		if pkg.Module != nil {
+			cache.modules[pkg.PkgPath] = pkg.Module.Dir
+		}
+
+		strict := cfg.isStrict(pkg.PkgPath, cache.dirs[pkg.PkgPath])
 		for _, file := range pkg.Syntax {
+			directives, derrs := ParseDirectives(pkg.Fset, file.Doc, cfg.Namespace)
+			if !cfg.IgnoreDirectiveErrors {
+				errs = append(errs, derrs...)
+			}
+			for _, d := range directives {
+				strict = strict || d.Name == DirectiveStrict
+			}
+		}
+
+		for _, file := range pkg.Syntax {
+			cache.collectWire(pkg, file)
+			if berrs := cache.collectBindings(pkg, file); !cfg.IgnoreDirectiveErrors {
+				errs = append(errs, berrs...)
+			}
+
 			for _, decl := range file.Decls {
 				switch g := decl.(type) {
 				case *ast.FuncDecl:
 					if !g.Name.IsExported() {
 						continue
 					}
+					// skip methods
+					if g.Recv != nil {
+						continue
+					}
 					obj := pkg.TypesInfo.ObjectOf(g.Name)
 					if obj == nil {
 						continue
 					}
-					cache.Add(newObject(obj, g.Doc))
+					o, derrs := NewAnnotatedObject(pkg.Fset, obj, g.Doc, cfg.Namespace)
+					if !cfg.IgnoreDirectiveErrors {
+						errs = append(errs, derrs...)
+					}
+					o.strict = strict
+					cache.Add(o)
 				case *ast.GenDecl:
 					for _, spec := range g.Specs {
 						t, ok := spec.(*ast.TypeSpec)
@@ -156,14 +298,27 @@ func buildCache(pkgs []*packages.Package) *ObjectCache {
 						if obj == nil {
 							continue
 						}
-						cache.Add(newObject(obj, g.Doc))
+						o, derrs := NewAnnotatedObject(pkg.Fset, obj, DocOf(g, t), cfg.Namespace)
+						if !cfg.IgnoreDirectiveErrors {
+							errs = append(errs, derrs...)
+						}
+						o.strict = strict
+						cache.Add(o)
 					}
 				}
 			}
 		}
 	}
 
-	return cache
+	cache.linkWire()
+	if berrs := cache.linkBindings(); !cfg.IgnoreDirectiveErrors {
+		errs = append(errs, berrs...)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return cache, nil
 }
 
 func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
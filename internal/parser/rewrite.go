@@ -0,0 +1,179 @@
+package parser
+
+import (
+	"fmt"
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"os"
+	"strings"
+
+	"github.com/yuemori/blueprinter/internal/logger"
+)
+
+// A Rewrite is a Go file whose provider directives are rewritten into another namespace.
+type Rewrite struct {
+	// Path is the path of the file.
+	Path string
+	// Src is the rewritten source code of the file.
+	Src []byte
+	// Count is the number of the rewritten directives.
+	Count int
+	// Warnings are the directives which are not rewritten since they are written with a prefix of another namespace,
+	// like `// acme:resolve FuncName` rewritten into 'blueprinter:'.
+	Warnings []*DirectiveError
+}
+
+// RewriteDirectives rewrites the provider directives written with LegacyPrefix in the files under cfg.Dir
+// into cfg.Namespace.Prefix, like `// provider:resolve FuncName` into `// blueprinter:resolve FuncName`.
+// Only the doc comments read by Parse are rewritten, and the files are not written.
+// A file which has only warnings is returned with Count 0.
+func RewriteDirectives(cfg *Config) ([]*Rewrite, []error) {
+	if err := cfg.Namespace.Validate(); err != nil {
+		return nil, []error{err}
+	}
+	if cfg.Namespace.Prefix == "" || cfg.Namespace.Prefix == LegacyPrefix {
+		return nil, []error{fmt.Errorf("the prefix to rewrite %s into must be given", LegacyPrefix)}
+	}
+
+	files, errs := collectGoFiles(cfg.Dir, cfg.Globs, cfg.Ignores)
+	if errs != nil {
+		return nil, errs
+	}
+
+	rewrites := make([]*Rewrite, 0)
+	errs = make([]error, 0)
+	for _, path := range files {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		fset := token.NewFileSet()
+		file, err := goparser.ParseFile(fset, path, src, goparser.ParseComments)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		rewritten, count, warnings := rewriteFile(fset, file, src, cfg.Namespace)
+		if count > 0 || len(warnings) > 0 {
+			logger.Debug("Rewrite:", path, count)
+			rewrites = append(rewrites, &Rewrite{Path: path, Src: rewritten, Count: count, Warnings: warnings})
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return rewrites, nil
+}
+
+// rewriteFile returns 'src' whose doc comments in 'file' are rewritten into 'ns', the number of the rewritten directives,
+// and the warnings of the directives written with a prefix of another namespace.
+// The package comment is rewritten as well, since it may have `provider:strict`.
+func rewriteFile(fset *token.FileSet, file *ast.File, src []byte, ns Namespace) ([]byte, int, []*DirectiveError) {
+	docs := []*ast.CommentGroup{file.Doc}
+	for _, decl := range file.Decls {
+		switch g := decl.(type) {
+		case *ast.FuncDecl:
+			if g.Recv == nil {
+				docs = append(docs, g.Doc)
+			}
+		case *ast.GenDecl:
+			for _, spec := range g.Specs {
+				if t, ok := spec.(*ast.TypeSpec); ok {
+					docs = append(docs, DocOf(g, t))
+				}
+			}
+		}
+	}
+
+	type edit struct {
+		start, end int
+		text       string
+	}
+	edits := make([]edit, 0)
+	count := 0
+	warnings := make([]*DirectiveError, 0)
+	for _, doc := range docs {
+		if doc == nil {
+			continue
+		}
+		for _, c := range doc.List {
+			text, n, ws := rewriteComment(c.Text, fset.Position(c.Pos()), ns)
+			warnings = append(warnings, ws...)
+			if n == 0 {
+				continue
+			}
+			count += n
+			edits = append(edits, edit{start: fset.Position(c.Pos()).Offset, end: fset.Position(c.End()).Offset, text: text})
+		}
+	}
+	if len(warnings) == 0 {
+		warnings = nil
+	}
+	if count == 0 {
+		return src, 0, warnings
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, e := range edits {
+		b.Write(src[last:e.start])
+		b.WriteString(e.text)
+		last = e.end
+	}
+	b.Write(src[last:])
+	return []byte(b.String()), count, warnings
+}
+
+// rewriteComment returns the comment 'text' at 'pos' whose directives written with LegacyPrefix are rewritten into 'ns',
+// the number of the rewritten directives, and the warnings of the directives written with a prefix of another namespace.
+func rewriteComment(text string, pos token.Position, ns Namespace) (string, int, []*DirectiveError) {
+	raws := strings.Split(text, "\n")
+	count := 0
+	warnings := make([]*DirectiveError, 0)
+	for i, line := range commentLines(text) {
+		linePos := pos
+		// Lines in a block comment are reported at their own lines.
+		if i > 0 {
+			linePos.Line += i
+			linePos.Column = 0
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		name, prefix, ok := Namespace{}.lookup(fields[0], len(fields) > 1)
+		if !ok {
+			if prefix, ok := foreignPrefix(fields[0], ns); ok {
+				warnings = append(warnings, &DirectiveError{Pos: linePos, Err: fmt.Errorf("%s is not rewritten, since it is written with %s instead of %s", fields[0], prefix, LegacyPrefix)})
+			}
+			continue
+		}
+		if prefix != LegacyPrefix {
+			continue
+		}
+		raws[i] = strings.Replace(raws[i], fields[0], ns.Format(name), 1)
+		count++
+	}
+	return strings.Join(raws, "\n"), count, warnings
+}
+
+// foreignPrefix returns the prefix of 'name' if it is a provider directive written with a prefix
+// other than LegacyPrefix and the one of 'ns', like 'acme:resolve'.
+func foreignPrefix(name string, ns Namespace) (string, bool) {
+	i := strings.Index(name, ":")
+	if i <= 0 {
+		return "", false
+	}
+	prefix := name[:i+1]
+	if prefix == LegacyPrefix || prefix == ns.Prefix {
+		return "", false
+	}
+	if _, ok := directiveSpecs[LegacyPrefix+name[i+1:]]; !ok {
+		return "", false
+	}
+	return prefix, true
+}
@@ -0,0 +1,39 @@
+package parser
+
+import (
+	goparser "go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestRewriteFile(t *testing.T) {
+	src := `// provider:strict
+package repo
+
+// NewRepo returns a Repo.
+// provider:resolve NewRepo
+// acme:primary
+type Repo struct{}
+`
+	want := `// blueprinter:strict
+package repo
+
+// NewRepo returns a Repo.
+// blueprinter:resolve NewRepo
+// acme:primary
+type Repo struct{}
+`
+	fset := token.NewFileSet()
+	file, err := goparser.ParseFile(fset, "repo.go", src, goparser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, count, warnings := rewriteFile(fset, file, []byte(src), Namespace{Prefix: "blueprinter:"})
+	if string(got) != want || count != 2 {
+		t.Errorf("rewriteFile() = %q, %d, want %q, 2", got, count, want)
+	}
+	if len(warnings) != 1 || warnings[0].Pos.Line != 6 {
+		t.Errorf("warnings = %v, want a warning of acme:primary at line 6", warnings)
+	}
+}
@@ -1,6 +1,15 @@
 package parser
 
-import "go/types"
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strings"
+)
+
+// tagKey is the struct tag key read by blueprinter.
+const tagKey = "blueprinter"
 
 type Struct struct {
 	*Object
@@ -9,3 +18,152 @@ type Struct struct {
 func (s *Struct) Type() *types.Struct {
 	return s.object.Type().Underlying().(*types.Struct)
 }
+
+// Fields returns the fields of the struct with their `blueprinter` tags parsed.
+func (s *Struct) Fields() ([]*Field, error) {
+	st := s.Type()
+	fields := make([]*Field, 0, st.NumFields())
+	for i := 0; i < st.NumFields(); i++ {
+		f, err := newField(st.Field(i), st.Tag(i))
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", s.String(), st.Field(i).Name(), err)
+		}
+		f.pos = s.position(st.Field(i).Pos())
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// Roots returns the constructors like 'path/to/package.NewFoo' given by `blueprinter:roots` on the container struct.
+func (s *Struct) Roots() []string {
+	roots := make([]string, 0)
+	for _, d := range s.directivesOf(DirectiveRoots) {
+		roots = append(roots, d.Args...)
+	}
+	return roots
+}
+
+// WireSets returns the provider sets of google/wire like 'path/to/package.ProviderSet' given by `blueprinter:wire` on the container struct.
+func (s *Struct) WireSets() []string {
+	sets := make([]string, 0)
+	for _, d := range s.directivesOf(DirectiveWire) {
+		sets = append(sets, d.Args...)
+	}
+	return sets
+}
+
+// A Field is a wrapper of a struct field.
+//
+// The behavior of the field is controlled by the `blueprinter` struct tag, which is
+// a comma separated list of options:
+//
+//	`blueprinter:"-"`           the field is never used.
+//	`blueprinter:"name=readDB"` the field is used only for params named readDB, and is filled like a param named readDB when the struct is injected.
+//	`blueprinter:"expose"`      a public getter is generated for the field.
+//	`blueprinter:"inject"`      the field is filled when the struct is marked as `provider:inject`.
+//	`blueprinter:"hook"`        the field is called by proxies of interfaces marked as `provider:proxy` after each call.
+//	`blueprinter:"before_hook"` the field is called by proxies of interfaces marked as `provider:proxy` before each call.
+//	`blueprinter:"optional"`    the field is left empty if no derivation is found when the struct is injected.
+type Field struct {
+	v          *types.Var
+	pos        token.Position
+	ignored    bool
+	qualifier  string
+	exposed    bool
+	injected   bool
+	hook       bool
+	beforeHook bool
+	optional   bool
+}
+
+func newField(v *types.Var, tag string) (*Field, error) {
+	f := &Field{v: v}
+
+	value, ok := reflect.StructTag(tag).Lookup(tagKey)
+	if !ok {
+		return f, nil
+	}
+
+	for _, opt := range strings.Split(value, ",") {
+		opt = strings.TrimSpace(opt)
+		switch {
+		case opt == "":
+		case opt == "-":
+			f.ignored = true
+		case opt == "expose":
+			f.exposed = true
+		case opt == "inject":
+			f.injected = true
+		case opt == "hook":
+			f.hook = true
+		case opt == "before_hook":
+			f.beforeHook = true
+		case opt == "optional":
+			f.optional = true
+		case strings.HasPrefix(opt, "name="):
+			f.qualifier = strings.TrimPrefix(opt, "name=")
+			if f.qualifier == "" {
+				return nil, fmt.Errorf("empty name in tag `%s:\"%s\"`", tagKey, value)
+			}
+		default:
+			return nil, fmt.Errorf("unknown option %q in tag `%s:\"%s\"`", opt, tagKey, value)
+		}
+	}
+
+	return f, nil
+}
+
+// Name returns the name of the field.
+func (f *Field) Name() string {
+	return f.v.Name()
+}
+
+// Position returns the position of the field, which is invalid if unknown.
+func (f *Field) Position() token.Position {
+	return f.pos
+}
+
+// Type returns the type of the field.
+func (f *Field) Type() types.Type {
+	return f.v.Type()
+}
+
+// Exported returns true if the field is exported.
+func (f *Field) Exported() bool {
+	return f.v.Exported()
+}
+
+// Ignored returns true if the field is tagged with `blueprinter:"-"`.
+func (f *Field) Ignored() bool {
+	return f.ignored
+}
+
+// Qualifier returns the name given by `blueprinter:"name=..."`, or an empty string.
+func (f *Field) Qualifier() string {
+	return f.qualifier
+}
+
+// Exposed returns true if the field is tagged with `blueprinter:"expose"`.
+func (f *Field) Exposed() bool {
+	return f.exposed
+}
+
+// Injected returns true if the field is tagged with `blueprinter:"inject"`.
+func (f *Field) Injected() bool {
+	return f.injected
+}
+
+// Hook returns true if the field is tagged with `blueprinter:"hook"`.
+func (f *Field) Hook() bool {
+	return f.hook
+}
+
+// BeforeHook returns true if the field is tagged with `blueprinter:"before_hook"`.
+func (f *Field) BeforeHook() bool {
+	return f.beforeHook
+}
+
+// Optional returns true if the field is tagged with `blueprinter:"optional"`.
+func (f *Field) Optional() bool {
+	return f.optional
+}
@@ -0,0 +1,481 @@
+package parser
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"reflect"
+	"sort"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// wirePkgPath is the import path of google/wire, whose provider sets are read statically from the AST.
+const wirePkgPath = "github.com/google/wire"
+
+// A WireSet is a provider set declared with `var Set = wire.NewSet(...)`, or the arguments of `wire.Build(...)`.
+// Provider sets included in the set are flattened.
+type WireSet struct {
+	name string
+	pos  token.Position
+
+	funcs    []*Func
+	structs  []*WireStruct
+	bindings []*WireBinding
+	// unsupported are the messages for the elements which can not be translated, such as `wire.Value`.
+	unsupported []string
+	// unexported are the providers which can not be called from the container, reported only if they are needed.
+	unexported []*Func
+	// unscanned are the names of the included sets which are not declared in the scanned packages.
+	unscanned []string
+
+	providers []*wireProvider
+	refs      []*wireRef
+}
+
+// A wireProvider is a function listed in a provider set, which is linked to the ObjectCache after all packages are loaded.
+type wireProvider struct {
+	fn  *types.Func
+	pos token.Position
+}
+
+// A wireRef is a provider set included in another provider set, referred as 'path/to/package.Name'.
+type wireRef struct {
+	name string
+	pos  token.Position
+}
+
+// A WireStruct is a struct provider given by `wire.Struct(new(T), "A", "B")`.
+type WireStruct struct {
+	*Struct
+	// fieldNames are the names of the fields to be filled. nil means "*", which fills all exported fields.
+	fieldNames []string
+	pos        token.Position
+}
+
+// A WireBinding is an interface binding given by `wire.Bind(new(Iface), new(Impl))`.
+type WireBinding struct {
+	iface *Iface
+	impl  types.Type
+	pos   token.Position
+}
+
+// A WireInjector is a function which calls `wire.Build`.
+type WireInjector struct {
+	fn  *types.Func
+	pos token.Position
+	set *WireSet
+}
+
+// Name returns a string like: 'path/to/package.ProviderSet'
+func (s *WireSet) Name() string {
+	return s.name
+}
+
+// Pos returns the position where the set is declared.
+func (s *WireSet) Pos() token.Position {
+	return s.pos
+}
+
+// Funcs returns the functions listed in the set.
+func (s *WireSet) Funcs() []*Func {
+	return s.funcs
+}
+
+// Structs returns the struct providers given by `wire.Struct`.
+func (s *WireSet) Structs() []*WireStruct {
+	return s.structs
+}
+
+// Bindings returns the interface bindings given by `wire.Bind`.
+func (s *WireSet) Bindings() []*WireBinding {
+	return s.bindings
+}
+
+// Unsupported returns the messages for the elements which can not be translated, such as `wire.Value`.
+func (s *WireSet) Unsupported() []string {
+	return s.unsupported
+}
+
+// Unexported returns the unexported functions listed in the set, which can not be called from the container.
+func (s *WireSet) Unexported() []*Func {
+	return s.unexported
+}
+
+// Unscanned returns the names of the included sets which are not declared in the scanned packages.
+func (s *WireSet) Unscanned() []string {
+	return s.unscanned
+}
+
+// Includes returns the names of the sets included directly, like 'path/to/package.ProviderSet'.
+func (s *WireSet) Includes() []string {
+	names := make([]string, 0, len(s.refs))
+	for _, ref := range s.refs {
+		names = append(names, ref.name)
+	}
+	return names
+}
+
+// Pos returns the position of `wire.Struct`.
+func (s *WireStruct) Pos() token.Position {
+	return s.pos
+}
+
+// FieldNames returns the names of the fields given to `wire.Struct`, or nil if "*" is given.
+func (s *WireStruct) FieldNames() []string {
+	return s.fieldNames
+}
+
+// InjectedFields returns the fields to be filled.
+// "*" selects all exported fields except those tagged with `wire:"-"`.
+func (s *WireStruct) InjectedFields() ([]*Field, error) {
+	all, err := s.Fields()
+	if err != nil {
+		return nil, err
+	}
+
+	st := s.Type()
+	if s.fieldNames == nil {
+		fields := make([]*Field, 0, len(all))
+		for i, f := range all {
+			if !f.Exported() || f.Ignored() || reflect.StructTag(st.Tag(i)).Get("wire") == "-" {
+				continue
+			}
+			fields = append(fields, f)
+		}
+		return fields, nil
+	}
+
+	fields := make([]*Field, 0, len(s.fieldNames))
+	for _, name := range s.fieldNames {
+		var found *Field
+		for _, f := range all {
+			if f.Name() == name {
+				found = f
+				break
+			}
+		}
+		if found == nil {
+			return nil, &ProviderError{Pos: s.pos, Err: fmt.Errorf("%s has no field named %s", s.String(), name)}
+		}
+		// An unexported field can not be filled by the generated code in the other package.
+		if !found.Exported() {
+			return nil, &ProviderError{Pos: s.pos, Err: fmt.Errorf("%s.%s is unexported and cannot be filled by the container", s.String(), name)}
+		}
+		fields = append(fields, found)
+	}
+	return fields, nil
+}
+
+// Iface returns the interface to be bound.
+func (b *WireBinding) Iface() *Iface {
+	return b.iface
+}
+
+// Impl returns the type bound to the interface.
+func (b *WireBinding) Impl() Type {
+	return b.impl
+}
+
+// Pos returns the position of `wire.Bind`.
+func (b *WireBinding) Pos() token.Position {
+	return b.pos
+}
+
+// Name returns the name of the injector function.
+func (i *WireInjector) Name() string {
+	return i.fn.Name()
+}
+
+// Pkg returns the package of the injector function.
+func (i *WireInjector) Pkg() *types.Package {
+	return i.fn.Pkg()
+}
+
+// Pos returns the position where the injector function is declared.
+func (i *WireInjector) Pos() token.Position {
+	return i.pos
+}
+
+// Signature returns the signature of the injector function.
+func (i *WireInjector) Signature() *types.Signature {
+	return i.fn.Type().(*types.Signature)
+}
+
+// Set returns the provider set given to `wire.Build`.
+func (i *WireInjector) Set() *WireSet {
+	return i.set
+}
+
+// WireSets returns all provider sets declared in the scanned packages, sorted by their names.
+func (c *ObjectCache) WireSets() []*WireSet {
+	return c.wireSets
+}
+
+// WireInjectors returns all injector functions declared in the scanned packages.
+// Note that injectors are usually excluded from the build without the 'wireinject' build tag.
+func (c *ObjectCache) WireInjectors() []*WireInjector {
+	return c.wireInjectors
+}
+
+// collectWire collects the provider sets declared as package level variables and the injectors in 'file'.
+func (c *ObjectCache) collectWire(pkg *packages.Package, file *ast.File) {
+	for _, decl := range file.Decls {
+		switch g := decl.(type) {
+		case *ast.GenDecl:
+			if g.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range g.Specs {
+				vs, ok := spec.(*ast.ValueSpec)
+				if !ok || len(vs.Names) != len(vs.Values) {
+					continue
+				}
+				for i, value := range vs.Values {
+					call, name := wireCallOf(pkg.TypesInfo, value)
+					if name != "NewSet" {
+						continue
+					}
+					setName := fmt.Sprintf("%s.%s", pkg.PkgPath, vs.Names[i].Name)
+					c.wireSets = append(c.wireSets, newWireSet(pkg, setName, call))
+				}
+			}
+		case *ast.FuncDecl:
+			if g.Recv != nil || g.Body == nil {
+				continue
+			}
+			fn, ok := pkg.TypesInfo.Defs[g.Name].(*types.Func)
+			if !ok {
+				continue
+			}
+			ast.Inspect(g.Body, func(n ast.Node) bool {
+				expr, ok := n.(ast.Expr)
+				if !ok {
+					return true
+				}
+				call, name := wireCallOf(pkg.TypesInfo, expr)
+				if name != "Build" {
+					return true
+				}
+				c.wireInjectors = append(c.wireInjectors, &WireInjector{
+					fn:  fn,
+					pos: pkg.Fset.Position(g.Pos()),
+					set: newWireSet(pkg, fmt.Sprintf("%s.%s", pkg.PkgPath, fn.Name()), call),
+				})
+				return false
+			})
+		}
+	}
+}
+
+// wireCallOf returns the call and the name of the google/wire function called by 'expr', such as 'NewSet'.
+// If 'expr' does not call a google/wire function, the name is empty.
+func wireCallOf(info *types.Info, expr ast.Expr) (*ast.CallExpr, string) {
+	call, ok := unparen(expr).(*ast.CallExpr)
+	if !ok {
+		return nil, ""
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return nil, ""
+	}
+	fn, ok := info.Uses[sel.Sel].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != wirePkgPath {
+		return nil, ""
+	}
+	return call, fn.Name()
+}
+
+// newWireSet reads the arguments of `wire.NewSet` or `wire.Build`.
+// The functions and the included sets are linked to the ObjectCache later by linkWire.
+func newWireSet(pkg *packages.Package, name string, call *ast.CallExpr) *WireSet {
+	info := pkg.TypesInfo
+	set := &WireSet{
+		name: name,
+		pos:  pkg.Fset.Position(call.Pos()),
+	}
+
+	for _, arg := range call.Args {
+		pos := pkg.Fset.Position(arg.Pos())
+
+		if inner, fn := wireCallOf(info, arg); inner != nil {
+			switch fn {
+			case "Bind":
+				named, ok := pointerElem(info, inner.Args[0]).(*types.Named)
+				if !ok || !types.IsInterface(named) {
+					set.unsupported = append(set.unsupported, fmt.Sprintf("%s: the first argument of wire.Bind must be a pointer to a named interface", pos))
+					continue
+				}
+				impl := pointerElem(info, inner.Args[1])
+				if impl == nil {
+					set.unsupported = append(set.unsupported, fmt.Sprintf("%s: the second argument of wire.Bind must be a pointer to a type", pos))
+					continue
+				}
+				iface, _ := newObject(named.Obj(), nil).Interface()
+				set.bindings = append(set.bindings, &WireBinding{iface: iface, impl: impl, pos: pos})
+			case "Struct":
+				t := pointerElem(info, inner.Args[0])
+				if ptr, ok := t.(*types.Pointer); ok {
+					t = ptr.Elem()
+				}
+				named, ok := t.(*types.Named)
+				if !ok {
+					set.unsupported = append(set.unsupported, fmt.Sprintf("%s: the first argument of wire.Struct must be a pointer to a named struct", pos))
+					continue
+				}
+				st, ok := newObject(named.Obj(), nil).Struct()
+				if !ok {
+					set.unsupported = append(set.unsupported, fmt.Sprintf("%s: the first argument of wire.Struct must be a pointer to a named struct", pos))
+					continue
+				}
+				names, err := wireFieldNames(info, inner.Args[1:])
+				if err != nil {
+					set.unsupported = append(set.unsupported, fmt.Sprintf("%s: %s", pos, err))
+					continue
+				}
+				set.structs = append(set.structs, &WireStruct{Struct: st, fieldNames: names, pos: pos})
+			default:
+				set.unsupported = append(set.unsupported, fmt.Sprintf("%s: wire.%s is not supported", pos, fn))
+			}
+			continue
+		}
+
+		var obj types.Object
+		switch e := unparen(arg).(type) {
+		case *ast.Ident:
+			obj = info.Uses[e]
+		case *ast.SelectorExpr:
+			obj = info.Uses[e.Sel]
+		}
+		switch obj := obj.(type) {
+		case *types.Func:
+			set.providers = append(set.providers, &wireProvider{fn: obj, pos: pos})
+		case *types.Var:
+			if obj.Pkg() == nil || obj.Parent() != obj.Pkg().Scope() {
+				set.unsupported = append(set.unsupported, fmt.Sprintf("%s: %s is not a provider set declared at package level", pos, types.ExprString(arg)))
+				continue
+			}
+			set.refs = append(set.refs, &wireRef{name: fmt.Sprintf("%s.%s", obj.Pkg().Path(), obj.Name()), pos: pos})
+		default:
+			set.unsupported = append(set.unsupported, fmt.Sprintf("%s: %s is not supported", pos, types.ExprString(arg)))
+		}
+	}
+
+	return set
+}
+
+// wireFieldNames returns the field names given to `wire.Struct`, or nil if "*" is given.
+func wireFieldNames(info *types.Info, args []ast.Expr) ([]string, error) {
+	names := make([]string, 0, len(args))
+	for _, arg := range args {
+		tv, ok := info.Types[arg]
+		if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+			return nil, fmt.Errorf("the field names of wire.Struct must be constant strings")
+		}
+		name := constant.StringVal(tv.Value)
+		if name == "*" {
+			return nil, nil
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// unparen returns 'expr' with any enclosing parentheses removed.
+func unparen(expr ast.Expr) ast.Expr {
+	for {
+		p, ok := expr.(*ast.ParenExpr)
+		if !ok {
+			return expr
+		}
+		expr = p.X
+	}
+}
+
+// pointerElem returns the type pointed by the type of 'expr' like `new(T)`, or nil.
+func pointerElem(info *types.Info, expr ast.Expr) types.Type {
+	ptr, ok := info.TypeOf(expr).(*types.Pointer)
+	if !ok {
+		return nil
+	}
+	return ptr.Elem()
+}
+
+// linkWire links the functions and the structs listed in the provider sets to the objects in the cache,
+// and flattens the included sets. Functions declared outside the scanned packages are not added to the cache,
+// since they are used only by the containers which apply the set.
+// Unexported functions and the sets declared outside the scanned packages are recorded as unsupported,
+// and reported by the resolver only if they are needed.
+func (c *ObjectCache) linkWire() {
+	sets := make(map[string]*WireSet)
+	all := append([]*WireSet{}, c.wireSets...)
+	for _, set := range c.wireSets {
+		sets[set.name] = set
+	}
+	for _, injector := range c.wireInjectors {
+		all = append(all, injector.set)
+	}
+
+	for _, set := range all {
+		for _, p := range set.providers {
+			// An unexported function can not be called by the generated code in the other package.
+			if !p.fn.Exported() {
+				fn, _ := newObject(p.fn, nil).Func()
+				set.unexported = append(set.unexported, fn)
+				set.unsupported = append(set.unsupported, fmt.Sprintf("%s: %s is unexported and cannot be called from the container", p.pos, p.fn.Name()))
+				continue
+			}
+			obj, ok := c.Get(p.fn.Pkg().Path(), p.fn.Name())
+			if !ok {
+				obj = newObject(p.fn, nil)
+			}
+			fn, _ := obj.Func()
+			set.funcs = append(set.funcs, fn)
+		}
+		// Prefer the objects in the cache, which have their comments.
+		for _, st := range set.structs {
+			if obj, ok := c.Get(st.ImportPath(), st.Name()); ok {
+				st.Struct = &Struct{obj}
+			}
+		}
+		for _, b := range set.bindings {
+			if obj, ok := c.Get(b.iface.ImportPath(), b.iface.Name()); ok {
+				b.iface = &Iface{obj}
+			}
+		}
+	}
+
+	flattened := make(map[*WireSet]bool)
+	var flatten func(set *WireSet, visiting map[*WireSet]bool)
+	flatten = func(set *WireSet, visiting map[*WireSet]bool) {
+		if flattened[set] || visiting[set] {
+			return
+		}
+		visiting[set] = true
+		for _, ref := range set.refs {
+			nested, ok := sets[ref.name]
+			if !ok {
+				set.unscanned = append(set.unscanned, ref.name)
+				set.unsupported = append(set.unsupported, fmt.Sprintf("%s: provider set %s is not declared in the scanned packages, add its package with --include-packages", ref.pos, ref.name))
+				continue
+			}
+			flatten(nested, visiting)
+			set.funcs = append(set.funcs, nested.funcs...)
+			set.structs = append(set.structs, nested.structs...)
+			set.bindings = append(set.bindings, nested.bindings...)
+			set.unsupported = append(set.unsupported, nested.unsupported...)
+			set.unexported = append(set.unexported, nested.unexported...)
+			set.unscanned = append(set.unscanned, nested.unscanned...)
+		}
+		flattened[set] = true
+	}
+	for _, set := range all {
+		flatten(set, make(map[*WireSet]bool))
+	}
+
+	sort.Slice(c.wireSets, func(i, j int) bool {
+		return c.wireSets[i].name < c.wireSets[j].name
+	})
+}
@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yuemori/blueprinter/internal/logger"
+	"golang.org/x/mod/modfile"
+)
+
+// WorkspaceRoots returns the directories of the modules listed in go.work for 'dir'.
+// If 'dir' is relative, the directories are returned as relative paths from the current directory.
+func WorkspaceRoots(dir string, env []string) ([]string, error) {
+	gowork, err := findGoWork(dir, env)
+	if err != nil {
+		return nil, err
+	}
+	logger.Debug("go.work Found:", gowork)
+
+	data, err := os.ReadFile(gowork)
+	if err != nil {
+		return nil, err
+	}
+	work, err := modfile.ParseWork(gowork, data, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	roots := make([]string, 0, len(work.Use))
+	for _, use := range work.Use {
+		root := use.Path
+		if !filepath.IsAbs(root) {
+			root = filepath.Join(filepath.Dir(gowork), root)
+		}
+		if !filepath.IsAbs(dir) {
+			if rel, err := filepath.Rel(cwd, root); err == nil {
+				root = rel
+			}
+		}
+		logger.Debug("Workspace Module Found:", root)
+		roots = append(roots, root)
+	}
+
+	return roots, nil
+}
+
+// findGoWork returns the path of go.work for 'dir' in the same way as the go command:
+// GOWORK in 'env' takes precedence, and otherwise go.work is looked up from 'dir' to its parents.
+func findGoWork(dir string, env []string) (string, error) {
+	gowork := ""
+	for _, e := range env {
+		if strings.HasPrefix(e, "GOWORK=") {
+			gowork = strings.TrimPrefix(e, "GOWORK=")
+		}
+	}
+	switch gowork {
+	case "off":
+		return "", fmt.Errorf("workspace mode is disabled by GOWORK=off")
+	case "", "auto":
+	default:
+		return gowork, nil
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for d := abs; ; d = filepath.Dir(d) {
+		path := filepath.Join(d, "go.work")
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+		if filepath.Dir(d) == d {
+			return "", fmt.Errorf("go.work is not found for %s", dir)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package resolver
+
+import (
+	"fmt"
+
+	"github.com/yuemori/blueprinter/internal/parser"
+)
+
+// CheckArchitecture returns the errors of the edges of 'g' violating the rules of 'arch'.
+// The layer of a constructor is the layer of the package declaring it.
+// A param derived through an interface depends on the layer of the package declaring the interface instead of
+// the one of the implementation, and the implementation depends on the layer of the interface as well,
+// so that the dependency inversion is allowed unless the interface itself is in a denied layer.
+func CheckArchitecture(g *Graph, cache *parser.ObjectCache, arch *parser.Architecture) []error {
+	if arch.IsEmpty() {
+		return nil
+	}
+
+	layerOf := func(pkgPath string) *parser.Layer {
+		return arch.LayerOf(pkgPath, cache.Dir(pkgPath))
+	}
+
+	errs := make([]error, 0)
+	seen := make(map[string]bool)
+	for _, e := range g.Edges {
+		via := e.Param.Name() + " " + parser.TypeNamePrefixedByImportPath(e.Param.Type())
+		if e.Iface == nil {
+			if e.To == nil {
+				continue
+			}
+			from, to := layerOf(e.From.ImportPath()), layerOf(e.To.ImportPath())
+			if rule := arch.Violation(from, to); rule != nil {
+				errs = append(errs, &LayerError{
+					From:    e.From.String(),
+					To:      e.To.String(),
+					FromPos: positionOf(e.From.constructor),
+					ToPos:   positionOf(e.To.constructor),
+					Rule:    rule.String(),
+					RulePos: rule.Pos,
+					msg: fmt.Sprintf("%s in the layer %s depends on %s in the layer %s through the param %s, which violates `%s` at %s",
+						e.From, from.Name, e.To, to.Name, via, rule, rule.Pos),
+				})
+			}
+			continue
+		}
+
+		iface := layerOf(e.Iface.ImportPath())
+		from := layerOf(e.From.ImportPath())
+		if rule := arch.Violation(from, iface); rule != nil {
+			errs = append(errs, &LayerError{
+				From:    e.From.String(),
+				To:      e.Iface.String(),
+				FromPos: positionOf(e.From.constructor),
+				ToPos:   e.Iface.Position(),
+				Rule:    rule.String(),
+				RulePos: rule.Pos,
+				msg: fmt.Sprintf("%s in the layer %s depends on the interface %s in the layer %s through the param %s, which violates `%s` at %s",
+					e.From, from.Name, e.Iface, iface.Name, via, rule, rule.Pos),
+			})
+		}
+
+		// The implementation depends on the interface it implements, which is checked once for each pair.
+		if e.To == nil || seen[e.To.String()+" "+e.Iface.String()] {
+			continue
+		}
+		seen[e.To.String()+" "+e.Iface.String()] = true
+		impl := layerOf(e.To.ImportPath())
+		if rule := arch.Violation(impl, iface); rule != nil {
+			errs = append(errs, &LayerError{
+				From:    e.To.String(),
+				To:      e.Iface.String(),
+				FromPos: positionOf(e.To.constructor),
+				ToPos:   e.Iface.Position(),
+				Rule:    rule.String(),
+				RulePos: rule.Pos,
+				msg: fmt.Sprintf("%s in the layer %s implements the interface %s in the layer %s, which violates `%s` at %s",
+					e.To, impl.Name, e.Iface, iface.Name, rule, rule.Pos),
+			})
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
@@ -0,0 +1,224 @@
+package resolver
+
+import (
+	"fmt"
+	"go/types"
+
+	"github.com/yuemori/blueprinter/internal/parser"
+
+	"github.com/pkg/errors"
+)
+
+// A constructor is a type that represents how to build a value from derived arguments,
+// such as a function call or a struct literal.
+type constructor interface {
+	Name() string
+	ImportPath() string
+	FullPkg() string
+	FullImportPath() string
+	String() string
+	MustBeResolved() bool
+	IsStrict() bool
+	IsPrimary() bool
+	IsRoot() bool
+
+	// imports returns the imports required by build.
+	imports() []string
+	// params returns the params to be derived, in the order of the arguments of build.
+	params() []*types.Var
+	// optional returns true if the i-th param may be left empty when no derivation is found.
+	optional(i int) bool
+	// result returns the type of the value built by the constructor.
+	result() types.Type
+	// build returns an expression that builds the value from 'args'.
+	build(library string, args []Derivation) string
+}
+
+var (
+	_ constructor = (*funcConstructor)(nil)
+	_ constructor = (*structConstructor)(nil)
+	_ constructor = (*decorator)(nil)
+)
+
+// A funcConstructor is a constructor that calls a function.
+type funcConstructor struct {
+	*parser.Func
+}
+
+func (c *funcConstructor) imports() []string {
+	return []string{c.FullImportPath()}
+}
+
+func (c *funcConstructor) params() []*types.Var {
+	vars := make([]*types.Var, 0, c.Params().Len())
+	for i := 0; i < c.Params().Len(); i++ {
+		vars = append(vars, c.Params().At(i))
+	}
+	return vars
+}
+
+func (c *funcConstructor) optional(i int) bool {
+	name := c.Params().At(i).Name()
+	for _, opt := range c.OptionalParams() {
+		if opt == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *funcConstructor) result() types.Type {
+	return c.Results().At(0).Type()
+}
+
+func (c *funcConstructor) build(library string, args []Derivation) string {
+	return c.call(library, c.Name(), nil, args)
+}
+
+// call returns an expression that calls the function referred as 'name' with 'leading' followed by 'args'.
+func (c *funcConstructor) call(library, name string, leading []string, args []Derivation) string {
+	format := qualifier(c, library) + "%s(\n"
+	values := []interface{}{name}
+	for _, arg := range leading {
+		format += "\t\t%s,\n"
+		values = append(values, arg)
+	}
+	for _, arg := range args {
+		format += "\t\t// %s\n\t\t%s,\n"
+		values = append(values, describe(arg), derive(arg))
+	}
+	format += "\t)"
+
+	return fmt.Sprintf(format, values...)
+}
+
+// A structConstructor is a constructor that builds a pointer to a struct marked as `provider:inject`
+// with a composite literal.
+//
+// If some fields are tagged with `blueprinter:"inject"`, only these fields are filled.
+// Otherwise, all exported fields except those tagged with `blueprinter:"-"` are filled.
+type structConstructor struct {
+	*parser.Struct
+	fields []*parser.Field
+}
+
+func newStructConstructor(s *parser.Struct) (*structConstructor, error) {
+	all, err := s.Fields()
+	if err != nil {
+		return nil, err
+	}
+
+	tagged := make([]*parser.Field, 0)
+	exported := make([]*parser.Field, 0)
+	for _, f := range all {
+		if f.Injected() {
+			// An unexported field can not be filled by the generated code in the other package.
+			if !f.Exported() {
+				return nil, errors.Errorf("%s.%s is unexported and cannot be tagged with `inject`", s.String(), f.Name())
+			}
+			tagged = append(tagged, f)
+		}
+		if f.Exported() && !f.Ignored() {
+			exported = append(exported, f)
+		}
+	}
+
+	if len(tagged) > 0 {
+		return &structConstructor{Struct: s, fields: tagged}, nil
+	}
+	return &structConstructor{Struct: s, fields: exported}, nil
+}
+
+func (c *structConstructor) imports() []string {
+	return []string{c.FullImportPath()}
+}
+
+func (c *structConstructor) params() []*types.Var {
+	vars := make([]*types.Var, 0, len(c.fields))
+	for _, f := range c.fields {
+		// A field tagged with `blueprinter:"name=..."` is filled like a param of the name.
+		name := f.Name()
+		if f.Qualifier() != "" {
+			name = f.Qualifier()
+		}
+		vars = append(vars, types.NewVar(0, nil, name, f.Type()))
+	}
+	return vars
+}
+
+func (c *structConstructor) optional(i int) bool {
+	return c.fields[i].Optional()
+}
+
+func (c *structConstructor) result() types.Type {
+	return types.NewPointer(c.Object.Type())
+}
+
+func (c *structConstructor) build(library string, args []Derivation) string {
+	format := "&" + qualifier(c, library) + "%s{\n"
+	values := []interface{}{c.Name()}
+	for i, arg := range args {
+		format += "\t\t// %s\n\t\t%s: %s,\n"
+		values = append(values, describe(arg), c.fields[i].Name(), derive(arg))
+	}
+	format += "\t}"
+
+	return fmt.Sprintf(format, values...)
+}
+
+// qualifier returns a package qualifier like 'github_com_owner_repo_pkg.' to refer 'c' from 'library'.
+func qualifier(c constructor, library string) string {
+	if c.ImportPath() == library {
+		return ""
+	}
+	return c.FullPkg() + "."
+}
+
+// decorateeName is the name of the variable holding the value to be decorated in generated code.
+const decorateeName = "v"
+
+// A decorator is a function marked as `provider:decorate`, whose shape is `func(Iface, deps...) Iface`.
+// The first param is the value to be decorated, and the rest params are derived like a constructor.
+type decorator struct {
+	*funcConstructor
+	iface *parser.Iface
+	order int
+}
+
+func newDecorator(fn *parser.Func, ifaces []*parser.Iface) (*decorator, error) {
+	if fn.Params().Len() == 0 || fn.Results().Len() != 1 {
+		return nil, errors.Errorf("decorator %s must be a shape of `func(Iface, deps...) Iface`", fn.String())
+	}
+	t := fn.Results().At(0).Type()
+	if !parser.Identical(fn.Params().At(0).Type(), t) {
+		return nil, errors.Errorf("decorator %s must take %s as the first param", fn.String(), parser.TypeNamePrefixedByImportPath(t))
+	}
+
+	order, err := fn.DecorateOrder()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, iface := range ifaces {
+		if parser.Identical(iface.Type(), t) {
+			return &decorator{
+				funcConstructor: &funcConstructor{fn},
+				iface:           iface,
+				order:           order,
+			}, nil
+		}
+	}
+	return nil, errors.Errorf("decorator %s must return an interface declared in the scanned packages", fn.String())
+}
+
+func (d *decorator) params() []*types.Var {
+	return d.funcConstructor.params()[1:]
+}
+
+func (d *decorator) optional(i int) bool {
+	return d.funcConstructor.optional(i + 1)
+}
+
+func (d *decorator) build(library string, args []Derivation) string {
+	return d.call(library, d.Name(), []string{decorateeName}, args)
+}
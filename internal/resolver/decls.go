@@ -2,6 +2,8 @@ package resolver
 
 import (
 	"fmt"
+	"go/types"
+	"unicode"
 
 	"github.com/yuemori/blueprinter/internal/parser"
 )
@@ -14,16 +16,55 @@ type Derivation interface {
 var (
 	_ Derivation = (*FieldDecl)(nil)
 	_ Derivation = (*PrivateFuncDecl)(nil)
+	_ Derivation = (*ZeroDecl)(nil)
 )
 
 // A FieldDecl is a type that represents a field of a resolver.
 type FieldDecl struct {
 	Name string
 	Type parser.Type
+	// Qualifier is the name given by `blueprinter:"name=..."`.
+	// If it is not empty, the field is only used for params with the same name.
+	Qualifier string
 }
 
 func (*FieldDecl) isDerivation() {}
 
+// A ZeroDecl is a type that represents the zero value passed to an optional param
+// for which no derivation is found.
+type ZeroDecl struct {
+	Name    string
+	Type    parser.Type
+	library string
+}
+
+func (*ZeroDecl) isDerivation() {}
+
+// Imports returns the imports required to refer the type of the zero value.
+func (z *ZeroDecl) Imports() []string {
+	if isNillable(z.Type) {
+		return nil
+	}
+	return parser.ImportPathsOf(z.Type, z.library)
+}
+
+// Expr returns the expression of the zero value.
+func (z *ZeroDecl) Expr() string {
+	if isNillable(z.Type) {
+		return "nil"
+	}
+	return fmt.Sprintf("*new(%s)", parser.LocalQualifiedTypeName(z.Type, z.library))
+}
+
+func isNillable(t parser.Type) bool {
+	switch t.Underlying().(type) {
+	case *types.Pointer, *types.Interface, *types.Map, *types.Slice, *types.Signature, *types.Chan:
+		return true
+	default:
+		return false
+	}
+}
+
 // A FuncDecl is a type that represents a function of a resolver.
 type FuncDecl interface {
 	FuncName() string
@@ -38,11 +79,12 @@ type FuncDecl interface {
 var (
 	_ FuncDecl = (*PublicFuncDecl)(nil)
 	_ FuncDecl = (*PrivateFuncDecl)(nil)
+	_ FuncDecl = (*FieldGetterDecl)(nil)
 )
 
 // A PublicFuncDecl is a type that represents a public function of a resolver.
 type PublicFuncDecl struct {
-	fn      *parser.Func
+	fn      constructor
 	library string
 	params  []Derivation
 }
@@ -56,39 +98,15 @@ func (p *PublicFuncDecl) Pkg() string {
 }
 
 func (p *PublicFuncDecl) FuncReturn() string {
-	return parser.QualifiedTypeName(p.fn.Results().At(0).Type())
+	return parser.QualifiedTypeName(p.fn.result())
 }
 
 func (p *PublicFuncDecl) FuncBody() string {
-	args := make([]interface{}, 0)
-	format := "\treturn "
-	if p.fn.ImportPath() != p.library {
-		format += "%s."
-		args = append(args, p.fn.FullPkg())
-	}
-	format += "%s(\n"
-	args = append(args, p.fn.Name())
-	for _, param := range p.params {
-		switch p := param.(type) {
-		case *FieldDecl:
-			format += "\t\t// %s\n"
-			args = append(args, parser.TypeNamePrefixedByImportPath(p.Type))
-			format += "\t\tf.%s,\n"
-			args = append(args, p.Name)
-		case *PrivateFuncDecl:
-			format += "\t\t// %s\n"
-			args = append(args, parser.TypeNamePrefixedByImportPath(p.ReturnType()))
-			format += "\t\tf.%s(),\n"
-			args = append(args, p.FuncName())
-		}
-	}
-	format += "\t)"
-
-	return fmt.Sprintf(format, args...)
+	return "\treturn " + p.fn.build(p.library, p.params)
 }
 
 func (p *PublicFuncDecl) Imports() []string {
-	return []string{p.fn.FullImportPath()}
+	return append([]string{p.fn.FullImportPath()}, zeroImports(p.params)...)
 }
 
 func (*PublicFuncDecl) isFuncDecl() {}
@@ -96,9 +114,20 @@ func (*PublicFuncDecl) isFuncDecl() {}
 // A PrivateFuncDecl is a type that represents a private function of a resolver.
 type PrivateFuncDecl struct {
 	iface   *parser.Iface
-	fn      *parser.Func
+	fn      constructor
 	params  []Derivation
 	library string
+
+	// decorations are applied to the value built by fn in order.
+	decorations []*decoration
+	// proxy wraps the decorated value if the interface is marked as `provider:proxy`.
+	proxy *ProxyDecl
+}
+
+// A decoration is a decorator with its derived params.
+type decoration struct {
+	fn     *decorator
+	params []Derivation
 }
 
 func (i *PrivateFuncDecl) FuncReturn() string {
@@ -113,7 +142,13 @@ func (i *PrivateFuncDecl) Pkg() string {
 }
 
 func (i *PrivateFuncDecl) Imports() []string {
-	return []string{i.iface.FullImportPath(), i.fn.FullImportPath()}
+	imports := append([]string{i.iface.FullImportPath()}, i.fn.imports()...)
+	imports = append(imports, zeroImports(i.params)...)
+	for _, d := range i.decorations {
+		imports = append(imports, d.fn.FullImportPath())
+		imports = append(imports, zeroImports(d.params)...)
+	}
+	return imports
 }
 
 func (i *PrivateFuncDecl) FuncName() string {
@@ -125,32 +160,110 @@ func (i *PrivateFuncDecl) ReturnType() parser.Type {
 }
 
 func (p *PrivateFuncDecl) FuncBody() string {
-	args := make([]interface{}, 0)
-	format := "\treturn "
-	if p.fn.ImportPath() != p.library {
-		format += "%s."
-		args = append(args, p.fn.FullPkg())
+	_, isSwitch := p.fn.(*switchConstructor)
+	if !isSwitch && len(p.decorations) == 0 && p.proxy == nil {
+		return "\treturn " + p.fn.build(p.library, p.params)
 	}
-	format += "%s(\n"
-	args = append(args, p.fn.Name())
-	for _, param := range p.params {
-		switch p := param.(type) {
-		case *FieldDecl:
-			format += "\t\t// %s\n"
-			args = append(args, parser.TypeNamePrefixedByImportPath(p.Type))
-			format += "\t\tf.%s,\n"
-			args = append(args, p.Name)
-		case *PrivateFuncDecl:
-			format += "\t\t// %s\n"
-			args = append(args, parser.TypeNamePrefixedByImportPath(p.ReturnType()))
-			format += "\t\tf.%s(),\n"
-			args = append(args, p.FuncName())
-		}
+
+	body := ""
+	if isSwitch {
+		body += fmt.Sprintf("\tvar %s %s\n%s\n", decorateeName, p.FuncReturn(), p.fn.build(p.library, p.params))
+	} else {
+		body += fmt.Sprintf("\tvar %s %s = %s\n", decorateeName, p.FuncReturn(), p.fn.build(p.library, p.params))
+	}
+	for _, d := range p.decorations {
+		body += fmt.Sprintf("\t%s = %s\n", decorateeName, d.fn.build(p.library, d.params))
+	}
+	if p.proxy != nil {
+		body += fmt.Sprintf("\treturn &%s{next: %s, c: f}", p.proxy.TypeName(), decorateeName)
+	} else {
+		body += "\treturn " + decorateeName
 	}
-	format += "\t)"
 
-	return fmt.Sprintf(format, args...)
+	return body
 }
 
 func (*PrivateFuncDecl) isFuncDecl()   {}
 func (*PrivateFuncDecl) isDerivation() {}
+
+// derive returns an expression that refers the value of the derivation from the receiver 'f'.
+func derive(d Derivation) string {
+	switch d := d.(type) {
+	case *FieldDecl:
+		return "f." + d.Name
+	case *PrivateFuncDecl:
+		return "f." + d.FuncName() + "()"
+	case *InstanceFuncDecl:
+		return "f." + d.FuncName() + "()"
+	case *ZeroDecl:
+		return d.Expr()
+	default:
+		panic(fmt.Sprintf("unknown derivation: %T", d))
+	}
+}
+
+// derivationType returns the type of the value derived by the derivation.
+func derivationType(d Derivation) parser.Type {
+	switch d := d.(type) {
+	case *FieldDecl:
+		return d.Type
+	case *PrivateFuncDecl:
+		return d.ReturnType()
+	case *InstanceFuncDecl:
+		return d.ReturnType()
+	case *ZeroDecl:
+		return d.Type
+	default:
+		panic(fmt.Sprintf("unknown derivation: %T", d))
+	}
+}
+
+// describe returns a comment for the argument derived by the derivation.
+func describe(d Derivation) string {
+	desc := parser.TypeNamePrefixedByImportPath(derivationType(d))
+	if _, ok := d.(*ZeroDecl); ok {
+		desc += " (optional, no derivation found)"
+	}
+	return desc
+}
+
+// zeroImports returns the imports required by the zero values in 'params'.
+func zeroImports(params []Derivation) []string {
+	imports := make([]string, 0)
+	for _, param := range params {
+		if z, ok := param.(*ZeroDecl); ok {
+			imports = append(imports, z.Imports()...)
+		}
+	}
+	return imports
+}
+
+// A FieldGetterDecl is a type that represents a public getter of a field tagged with `blueprinter:"expose"`.
+type FieldGetterDecl struct {
+	field   *FieldDecl
+	library string
+}
+
+func (g *FieldGetterDecl) FuncName() string {
+	r := []rune(g.field.Name)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+func (g *FieldGetterDecl) Pkg() string {
+	return g.library
+}
+
+func (g *FieldGetterDecl) FuncReturn() string {
+	return parser.LocalQualifiedTypeName(g.field.Type, g.library)
+}
+
+func (g *FieldGetterDecl) FuncBody() string {
+	return fmt.Sprintf("\treturn f.%s", g.field.Name)
+}
+
+func (g *FieldGetterDecl) Imports() []string {
+	return parser.ImportPathsOf(g.field.Type, g.library)
+}
+
+func (*FieldGetterDecl) isFuncDecl() {}
@@ -0,0 +1,87 @@
+package resolver
+
+import (
+	"go/token"
+	"go/types"
+)
+
+// A Candidate is one of the implementations or the constructors of an ambiguous interface.
+type Candidate struct {
+	Name string
+	// Pos is the position of the declaration, which is invalid if unknown.
+	Pos token.Position
+}
+
+// An AmbiguousError is an error of an interface which has more than one implementation or constructor to be bound.
+type AmbiguousError struct {
+	// Iface is the interface like 'path/to/package.Iface'.
+	Iface      string
+	Pos        token.Position
+	Candidates []*Candidate
+	msg        string
+}
+
+func (e *AmbiguousError) Error() string {
+	return e.msg
+}
+
+// A ParamsError is an error of a constructor whose params cannot be derived.
+type ParamsError struct {
+	// Constructor is the constructor like 'path/to/package.NewFoo'.
+	Constructor string
+	Pos         token.Position
+	// Errs are the errors of the params.
+	Errs []error
+	msg  string
+}
+
+func (e *ParamsError) Error() string {
+	return e.msg
+}
+
+// A RootError is an error of a root given by `provider:root` or `blueprinter:roots` which cannot be resolved.
+type RootError struct {
+	// Root is the constructor like 'path/to/package.NewFoo'.
+	Root string
+	Pos  token.Position
+	Err  error
+}
+
+func (e *RootError) Error() string {
+	return "unable to resolve the root " + e.Root + ": " + e.Err.Error()
+}
+
+func (e *RootError) Unwrap() error {
+	return e.Err
+}
+
+// A LayerError is an error of a dependency violating a rule of the architecture.
+type LayerError struct {
+	// From and To are the constructors, and FromPos and ToPos are their positions.
+	From, To       string
+	FromPos, ToPos token.Position
+	// Rule is the violated rule like 'blueprinter:deny domain infra', and RulePos is its position.
+	Rule    string
+	RulePos token.Position
+	msg     string
+}
+
+func (e *LayerError) Error() string {
+	return e.msg
+}
+
+// positionOf returns the position of the declaration of 'c', which is invalid if unknown.
+func positionOf(c constructor) token.Position {
+	if p, ok := c.(positioned); ok {
+		return p.Position()
+	}
+	return token.Position{}
+}
+
+// typePosition returns the position of the declaration of the type 't' in the scanned packages.
+func (r *Resolver) typePosition(t types.Type) token.Position {
+	if obj, ok := r.cache.ObjectOf(t); ok {
+		return obj.Position()
+	}
+	return token.Position{}
+}
@@ -0,0 +1,324 @@
+package resolver
+
+import (
+	"fmt"
+	"go/types"
+	"regexp"
+	"strings"
+
+	"github.com/yuemori/blueprinter/internal/parser"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	_ constructor = (*genericConstructor)(nil)
+	_ FuncDecl    = (*InstanceFuncDecl)(nil)
+	_ Derivation  = (*InstanceFuncDecl)(nil)
+
+	// Match characters which can not be used in identifiers
+	nonIdentRegexp = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+)
+
+// A genericConstructor is a constructor that calls a generic function instantiated with type arguments.
+type genericConstructor struct {
+	*funcConstructor
+	typeArgs []types.Type
+	sig      *types.Signature
+}
+
+// instantiate instantiates the generic function 'fn' with 'typeArgs' in 'ctxt',
+// which is owned by each resolver so that containers can be resolved in parallel.
+func instantiate(ctxt *types.Context, fn *parser.Func, typeArgs []types.Type) (*genericConstructor, error) {
+	inst, err := types.Instantiate(ctxt, fn.Signature(), typeArgs, true)
+	if err != nil {
+		return nil, err
+	}
+	return &genericConstructor{
+		funcConstructor: &funcConstructor{fn},
+		typeArgs:        typeArgs,
+		sig:             inst.(*types.Signature),
+	}, nil
+}
+
+func (c *genericConstructor) String() string {
+	args := make([]string, 0, len(c.typeArgs))
+	for _, t := range c.typeArgs {
+		args = append(args, parser.TypeNamePrefixedByImportPath(t))
+	}
+	return fmt.Sprintf("%s[%s]", c.funcConstructor.String(), strings.Join(args, ", "))
+}
+
+func (c *genericConstructor) imports() []string {
+	imports := c.funcConstructor.imports()
+	for _, t := range c.typeArgs {
+		imports = append(imports, parser.ImportPathsOf(t, "")...)
+	}
+	return imports
+}
+
+func (c *genericConstructor) params() []*types.Var {
+	vars := make([]*types.Var, 0, c.sig.Params().Len())
+	for i := 0; i < c.sig.Params().Len(); i++ {
+		vars = append(vars, c.sig.Params().At(i))
+	}
+	return vars
+}
+
+func (c *genericConstructor) result() types.Type {
+	return c.sig.Results().At(0).Type()
+}
+
+func (c *genericConstructor) build(library string, args []Derivation) string {
+	typeArgs := make([]string, 0, len(c.typeArgs))
+	for _, t := range c.typeArgs {
+		typeArgs = append(typeArgs, parser.LocalQualifiedTypeName(t, library))
+	}
+	return c.call(library, fmt.Sprintf("%s[%s]", c.Name(), strings.Join(typeArgs, ", ")), nil, args)
+}
+
+// An InstanceFuncDecl is a type that represents a private function of a resolver,
+// which builds an instantiation of a generic type, like 'Repository[User]', with an instantiated generic constructor.
+type InstanceFuncDecl struct {
+	typ     parser.Type
+	fn      constructor
+	params  []Derivation
+	library string
+}
+
+func (i *InstanceFuncDecl) FuncName() string {
+	name := strings.Replace(parser.QualifiedTypeName(i.typ), "*", "ptr_", -1)
+	return strings.Trim(nonIdentRegexp.ReplaceAllString(name, "_"), "_")
+}
+
+func (i *InstanceFuncDecl) Pkg() string {
+	return namedOf(i.typ).Obj().Pkg().Path()
+}
+
+func (i *InstanceFuncDecl) FuncReturn() string {
+	return parser.LocalQualifiedTypeName(i.typ, i.library)
+}
+
+func (i *InstanceFuncDecl) Imports() []string {
+	imports := parser.ImportPathsOf(i.typ, i.library)
+	imports = append(imports, i.fn.imports()...)
+	return append(imports, zeroImports(i.params)...)
+}
+
+func (i *InstanceFuncDecl) ReturnType() parser.Type {
+	return i.typ
+}
+
+func (i *InstanceFuncDecl) FuncBody() string {
+	return "\treturn " + i.fn.build(i.library, i.params)
+}
+
+func (*InstanceFuncDecl) isFuncDecl()   {}
+func (*InstanceFuncDecl) isDerivation() {}
+
+// findInstance finds or builds the derivation for 't' if it is an instantiation of a generic type.
+// If 't' is not an instantiation, this function returns nil without errors.
+// Optional params of the constructor are left empty only if 'lenient' is true.
+func (r *Resolver) findInstance(t parser.Type, lenient bool) (*InstanceFuncDecl, error) {
+	named := namedOf(t)
+	if named == nil || named.TypeArgs().Len() == 0 {
+		return nil, nil
+	}
+
+	key := parser.TypeNamePrefixedByImportPath(t)
+	if decl, ok := r.instances[key]; ok {
+		return decl, nil
+	}
+	if r.instantiating[key] {
+		return nil, errors.Errorf("circular dependency found for %s", key)
+	}
+	r.instantiating[key] = true
+	defer delete(r.instantiating, key)
+
+	fns, err := r.instantiationsFor(t, named)
+	if err != nil {
+		return nil, err
+	}
+	if len(fns) == 0 {
+		return nil, errors.Errorf("no generic constructors found for %s", key)
+	}
+
+	// If multiple constructors can be instantiated, prefer the ones marked as 'provider:primary'.
+	if len(fns) > 1 {
+		primaries := make([]constructor, 0)
+		for _, fn := range fns {
+			if fn.IsPrimary() {
+				primaries = append(primaries, fn)
+			}
+		}
+		if len(primaries) > 0 {
+			fns = primaries
+		}
+	}
+	if len(fns) != 1 {
+		names := make([]string, 0, len(fns))
+		for _, fn := range fns {
+			names = append(names, fn.String())
+		}
+		return nil, errors.Errorf("more than one generic constructors are found for %s: %s", key, strings.Join(names, ", "))
+	}
+
+	params, err := r.findDerivationsForParams(fns[0], lenient)
+	if err != nil {
+		return nil, err
+	}
+	reportEmptyOptionals(fns[0], params)
+
+	decl := &InstanceFuncDecl{
+		typ:     t,
+		fn:      fns[0],
+		params:  params,
+		library: r.library,
+	}
+	r.instances[key] = decl
+	r.instanceKeys = append(r.instanceKeys, key)
+
+	return decl, nil
+}
+
+// discardInstances discards the instances built after the first 'n' ones.
+func (r *Resolver) discardInstances(n int) {
+	for _, key := range r.instanceKeys[n:] {
+		delete(r.instances, key)
+	}
+	r.instanceKeys = r.instanceKeys[:n]
+}
+
+// instantiationsFor returns the generic constructors instantiated to build a value of 't'.
+//
+// If 't' is an instantiation of a generic interface, the type params of the constructors are
+// assumed to correspond to the type args of the interface in order, like 'NewSQLRepository[T]' for 'Repository[T]'.
+// Otherwise, the type args are inferred from the result type of the constructors.
+func (r *Resolver) instantiationsFor(t parser.Type, named *types.Named) ([]constructor, error) {
+	typeArgs := make([]types.Type, 0, named.TypeArgs().Len())
+	for i := 0; i < named.TypeArgs().Len(); i++ {
+		typeArgs = append(typeArgs, named.TypeArgs().At(i))
+	}
+	isIface := types.IsInterface(t)
+
+	// In the case where 'provider:resolve' is specified for the generic interface
+	if obj, ok := r.cache.ObjectOf(named.Origin()); ok && isIface && obj.IsResolve() {
+		pkg, name, err := obj.ResolvedPkgAndFuncName()
+		if err != nil {
+			return nil, err
+		}
+		o, ok := r.cache.Get(pkg, name)
+		if !ok {
+			return nil, errors.Errorf("%s does not found %s.%s", obj.Name(), pkg, name)
+		}
+		fn, ok := o.Func()
+		if !ok || !fn.IsGeneric() {
+			return nil, errors.Errorf("%s.%s is not a generic function: %s", pkg, name, obj.Name())
+		}
+		c, err := instantiate(r.typesContext, fn, typeArgs)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to instantiate %s for %s", fn.String(), parser.TypeNamePrefixedByImportPath(t))
+		}
+		if !types.AssignableTo(c.result(), t) {
+			return nil, errors.Errorf("%s does not implement %s", c.String(), parser.TypeNamePrefixedByImportPath(t))
+		}
+		return []constructor{c}, nil
+	}
+
+	fns := make([]constructor, 0)
+	for _, fn := range r.cache.Funcs() {
+		if !fn.IsInstantiable() {
+			continue
+		}
+
+		args := typeArgs
+		if isIface {
+			if fn.Signature().TypeParams().Len() != len(typeArgs) {
+				continue
+			}
+		} else {
+			inferred, ok := infer(fn, t)
+			if !ok {
+				continue
+			}
+			args = inferred
+		}
+
+		// The constructor can not be instantiated if the type args do not satisfy the constraints.
+		c, err := instantiate(r.typesContext, fn, args)
+		if err != nil {
+			continue
+		}
+		if isIface && !types.AssignableTo(c.result(), t) {
+			continue
+		}
+		if !isIface && !types.Identical(c.result(), t) {
+			continue
+		}
+		fns = append(fns, c)
+	}
+
+	return fns, nil
+}
+
+// infer infers the type args of the generic function 'fn' which returns 't'.
+func infer(fn *parser.Func, t types.Type) ([]types.Type, bool) {
+	tparams := fn.Signature().TypeParams()
+	bindings := make(map[*types.TypeParam]types.Type)
+	if !unify(fn.Results().At(0).Type(), t, bindings) {
+		return nil, false
+	}
+
+	args := make([]types.Type, 0, tparams.Len())
+	for i := 0; i < tparams.Len(); i++ {
+		arg, ok := bindings[tparams.At(i)]
+		if !ok {
+			return nil, false
+		}
+		args = append(args, arg)
+	}
+	return args, true
+}
+
+// unify reports whether 'x', which may contain type params, matches 'y', and records the type params in 'bindings'.
+func unify(x, y types.Type, bindings map[*types.TypeParam]types.Type) bool {
+	switch x := x.(type) {
+	case *types.TypeParam:
+		if bound, ok := bindings[x]; ok {
+			return types.Identical(bound, y)
+		}
+		bindings[x] = y
+		return true
+	case *types.Pointer:
+		y, ok := y.(*types.Pointer)
+		return ok && unify(x.Elem(), y.Elem(), bindings)
+	case *types.Slice:
+		y, ok := y.(*types.Slice)
+		return ok && unify(x.Elem(), y.Elem(), bindings)
+	case *types.Map:
+		y, ok := y.(*types.Map)
+		return ok && unify(x.Key(), y.Key(), bindings) && unify(x.Elem(), y.Elem(), bindings)
+	case *types.Named:
+		y, ok := y.(*types.Named)
+		if !ok || x.Origin().Obj() != y.Origin().Obj() || x.TypeArgs().Len() != y.TypeArgs().Len() {
+			return false
+		}
+		for i := 0; i < x.TypeArgs().Len(); i++ {
+			if !unify(x.TypeArgs().At(i), y.TypeArgs().At(i), bindings) {
+				return false
+			}
+		}
+		return true
+	default:
+		return types.Identical(x, y)
+	}
+}
+
+// namedOf returns the named type 't' or the named type pointed by 't'.
+func namedOf(t types.Type) *types.Named {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, _ := t.(*types.Named)
+	return named
+}
@@ -0,0 +1,187 @@
+package resolver
+
+import (
+	"go/types"
+
+	"github.com/yuemori/blueprinter/internal/parser"
+)
+
+// A Node is a constructor called by the container, such as a function or a struct marked as `provider:inject`.
+type Node struct {
+	constructor constructor
+	// Public is true if the container has a public method for the node.
+	Public bool
+}
+
+// Name returns the name of the constructor.
+func (n *Node) Name() string {
+	return n.constructor.Name()
+}
+
+// ImportPath returns the import path of the package declaring the constructor.
+func (n *Node) ImportPath() string {
+	return n.constructor.ImportPath()
+}
+
+// String returns a string like 'path/to/package.NewFoo'.
+func (n *Node) String() string {
+	return n.constructor.String()
+}
+
+// Func returns the function called by the node, or false if the node builds a struct literal.
+func (n *Node) Func() (*parser.Func, bool) {
+	switch c := n.constructor.(type) {
+	case *funcConstructor:
+		return c.Func, true
+	case *genericConstructor:
+		return c.Func, true
+	case *decorator:
+		return c.Func, true
+	}
+	return nil, false
+}
+
+// An Edge is a dependency of a param of a constructor.
+// A param derived from a decorated interface has the edges to the decorators as well as to the constructor.
+type Edge struct {
+	From *Node
+	// Param is the param of From.
+	Param *types.Var
+	// To is the constructor deriving the param.
+	// It is nil if the param is derived from a field of the container or left empty.
+	To *Node
+	// Iface is the interface through which the param is derived, if any.
+	Iface *parser.Iface
+	// Field is the field of the container deriving the param, if any.
+	Field *FieldDecl
+}
+
+// A Graph is the dependencies between the constructors called by a container.
+type Graph struct {
+	Nodes []*Node
+	Edges []*Edge
+
+	nodes map[string]*Node
+	// called is the set of the nodes whose edges are added.
+	called map[string]bool
+	// unused is set by ResolveGraph.
+	unused []*Unused
+}
+
+// ResolveGraph resolves the container 'target' in the package 'library' like Resolve,
+// and returns the dependency graph instead of the data for the template.
+// The items not used by the container are also found, see Graph.Unused.
+// If the container has errors, the graph of the constructors resolved in spite of them is returned with the errors.
+func ResolveGraph(cache *parser.ObjectCache, target, library string) (*Graph, error, []error) {
+	providerImpl, err := loadProviderImpl(cache, library, target)
+	if err != nil {
+		return nil, err, nil
+	}
+
+	resolver, err := NewResolver(providerImpl, cache, library)
+	if err != nil {
+		return nil, err, nil
+	}
+	decls, errs := resolver.Resolve()
+	g := NewGraph(decls)
+	if errs != nil {
+		return g, nil, errs
+	}
+	g.unused = resolver.findUnused(g, decls)
+	return g, nil, nil
+}
+
+// NewGraph returns the dependency graph of 'decls' returned by Resolver.Resolve.
+func NewGraph(decls []FuncDecl) *Graph {
+	g := &Graph{
+		Nodes:  make([]*Node, 0),
+		Edges:  make([]*Edge, 0),
+		nodes:  make(map[string]*Node),
+		called: make(map[string]bool),
+	}
+
+	for _, decl := range decls {
+		switch d := decl.(type) {
+		case *PublicFuncDecl:
+			g.addCall(d.fn, d.params, true)
+		case *PrivateFuncDecl:
+			g.addCall(d.fn, d.params, false)
+			for _, dec := range d.decorations {
+				g.addCall(dec.fn, dec.params, false)
+			}
+		case *InstanceFuncDecl:
+			g.addCall(d.fn, d.params, false)
+		}
+	}
+
+	return g
+}
+
+// Node returns the node of the constructor like 'path/to/package.NewFoo', or false.
+func (g *Graph) Node(name string) (*Node, bool) {
+	n, ok := g.nodes[name]
+	return n, ok
+}
+
+// addCall adds the nodes called by 'c' with 'args', and the edges of their params.
+// The candidates of a switch are added as the nodes of their own.
+func (g *Graph) addCall(c constructor, args []Derivation, public bool) {
+	if s, ok := c.(*switchConstructor); ok {
+		for _, sc := range s.cases {
+			n := len(sc.fn.params())
+			g.addCall(sc.fn, args[:n], public)
+			args = args[n:]
+		}
+		return
+	}
+
+	from := g.node(c)
+	from.Public = from.Public || public
+	if g.called[c.String()] {
+		return
+	}
+	g.called[c.String()] = true
+
+	for i, param := range c.params() {
+		switch d := args[i].(type) {
+		case *FieldDecl:
+			g.Edges = append(g.Edges, &Edge{From: from, Param: param, Field: d})
+		case *PrivateFuncDecl:
+			for _, to := range g.targets(d.fn) {
+				g.Edges = append(g.Edges, &Edge{From: from, Param: param, To: to, Iface: d.iface})
+			}
+			// The value of the param is also built by the decorators.
+			for _, dec := range d.decorations {
+				g.Edges = append(g.Edges, &Edge{From: from, Param: param, To: g.node(dec.fn), Iface: d.iface})
+			}
+		case *InstanceFuncDecl:
+			for _, to := range g.targets(d.fn) {
+				g.Edges = append(g.Edges, &Edge{From: from, Param: param, To: to})
+			}
+		}
+	}
+}
+
+// targets returns the nodes of the constructor 'c', or the nodes of the candidates if 'c' is a switch.
+func (g *Graph) targets(c constructor) []*Node {
+	s, ok := c.(*switchConstructor)
+	if !ok {
+		return []*Node{g.node(c)}
+	}
+	nodes := make([]*Node, 0, len(s.cases))
+	for _, sc := range s.cases {
+		nodes = append(nodes, g.node(sc.fn))
+	}
+	return nodes
+}
+
+// node returns the node of 'c', adding it if missing.
+func (g *Graph) node(c constructor) *Node {
+	if n, ok := g.nodes[c.String()]; ok {
+		return n
+	}
+	n := &Node{constructor: c}
+	g.nodes[c.String()] = n
+	g.Nodes = append(g.Nodes, n)
+	return n
+}
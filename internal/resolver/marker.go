@@ -0,0 +1,53 @@
+package resolver
+
+import (
+	"github.com/yuemori/blueprinter/internal/parser"
+
+	"github.com/pkg/errors"
+)
+
+// setupMarkerBindings builds bindings for the interfaces given by `blueprint.Bind`.
+// It must be called before setupBindings, since these bindings take precedence over the ones found from the implementations.
+func (r *Resolver) setupMarkerBindings() []error {
+	errs := make([]error, 0)
+
+	for _, b := range r.cache.Bindings() {
+		iface := b.Iface()
+		if iface.IsResolve() || iface.IsSwitch() {
+			errs = append(errs, &parser.ProviderError{Pos: b.Pos(), Err: errors.Errorf("%s is bound by blueprint.Bind, and cannot have `provider:resolve` or `provider:switch`", iface.String())})
+			continue
+		}
+
+		fn := b.Func()
+		// Functions without params can be bound as well as the ones given by `provider:resolve`.
+		if fn.IsGeneric() || fn.Results().Len() != 1 {
+			errs = append(errs, &parser.ProviderError{Pos: b.Pos(), Err: errors.Errorf("%s can not be bound to %s: it must be a non-generic function returning a single value", fn.String(), iface.String())})
+			continue
+		}
+		if !parser.AssignableTo(fn.Results().At(0).Type(), iface.Type()) {
+			errs = append(errs, &parser.ProviderError{Pos: b.Pos(), Err: errors.Errorf("%s does not implement %s", fn.String(), iface.String())})
+			continue
+		}
+
+		if prev, ok := r.explicitlyBound(iface); ok {
+			errs = append(errs, &parser.ProviderError{Pos: b.Pos(), Err: errors.Errorf("%s is bound to both %s and %s", iface.String(), prev.String(), fn.String())})
+			continue
+		}
+		r.bindings[iface] = &funcConstructor{fn}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// explicitlyBound returns the constructor bound to 'iface' by blueprint.Bind or wire.Bind.
+func (r *Resolver) explicitlyBound(iface *parser.Iface) (constructor, bool) {
+	for bound, fn := range r.bindings {
+		if bound.String() == iface.String() {
+			return fn, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,202 @@
+package resolver
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+
+	"github.com/yuemori/blueprinter/internal/parser"
+
+	"github.com/pkg/errors"
+)
+
+// A TypeDecl is a type that represents a type declared in the generated code.
+type TypeDecl interface {
+	TypeName() string
+	TypeBody() string
+	Imports() []string
+	Pkg() string
+
+	isTypeDecl()
+}
+
+var _ TypeDecl = (*ProxyDecl)(nil)
+
+// A ProxyDecl is a type that represents a proxy of an interface marked as `provider:proxy`.
+// The proxy implements every method of the interface by delegating to the bound implementation,
+// and reports each call to the fields of the container tagged with `blueprinter:"before_hook"` before the call
+// and `blueprinter:"hook"` after the call. Either of them may be omitted.
+type ProxyDecl struct {
+	iface      *parser.Iface
+	hook       *parser.Field
+	beforeHook *parser.Field
+	container  string
+	library    string
+}
+
+func newProxyDecl(iface *parser.Iface, hook, beforeHook *parser.Field, container, library string) (*ProxyDecl, error) {
+	if hook == nil && beforeHook == nil {
+		return nil, errors.Errorf(
+			"%s is marked as `provider:proxy`, but %s has no field tagged with `blueprinter:\"hook\"` or `blueprinter:\"before_hook\"`",
+			iface.String(), container)
+	}
+
+	it := iface.Interface()
+	for i := 0; i < it.NumMethods(); i++ {
+		if m := it.Method(i); !m.Exported() && iface.ImportPath() != library {
+			return nil, errors.Errorf("%s is marked as `provider:proxy`, but has the unexported method %s", iface.String(), m.Name())
+		}
+	}
+
+	return &ProxyDecl{
+		iface:      iface,
+		hook:       hook,
+		beforeHook: beforeHook,
+		container:  container,
+		library:    library,
+	}, nil
+}
+
+func (p *ProxyDecl) TypeName() string {
+	return p.iface.FullPkg() + "_" + p.iface.Name() + "Proxy"
+}
+
+func (p *ProxyDecl) Pkg() string {
+	return p.iface.ImportPath()
+}
+
+func (p *ProxyDecl) Imports() []string {
+	imports := []string{}
+	if p.hook != nil {
+		imports = append(imports, `time "time"`)
+	}
+	imports = append(imports, parser.ImportPathsOf(p.iface.Type(), p.library)...)
+
+	it := p.iface.Interface()
+	for i := 0; i < it.NumMethods(); i++ {
+		imports = append(imports, parser.ImportPathsOf(it.Method(i).Type(), p.library)...)
+	}
+	return imports
+}
+
+func (p *ProxyDecl) TypeBody() string {
+	body := fmt.Sprintf("type %s struct {\n\tnext %s\n\tc    *%s\n}\n",
+		p.TypeName(), parser.LocalQualifiedTypeName(p.iface.Type(), p.library), p.container)
+
+	it := p.iface.Interface()
+	for i := 0; i < it.NumMethods(); i++ {
+		body += "\n" + p.method(it.Method(i))
+	}
+
+	return body
+}
+
+// method returns the declaration of the method 'm' of the proxy.
+func (p *ProxyDecl) method(m *types.Func) string {
+	sig := m.Type().(*types.Signature)
+
+	params := make([]string, 0, sig.Params().Len())
+	names := make([]string, 0, sig.Params().Len())
+	args := make([]string, 0, sig.Params().Len())
+	for i := 0; i < sig.Params().Len(); i++ {
+		name := fmt.Sprintf("a%d", i)
+		t := sig.Params().At(i).Type()
+		names = append(names, name)
+		if sig.Variadic() && i == sig.Params().Len()-1 {
+			params = append(params, fmt.Sprintf("%s ...%s", name, parser.LocalQualifiedTypeName(t.(*types.Slice).Elem(), p.library)))
+			args = append(args, name+"...")
+			continue
+		}
+		params = append(params, fmt.Sprintf("%s %s", name, parser.LocalQualifiedTypeName(t, p.library)))
+		args = append(args, name)
+	}
+
+	results := make([]string, 0, sig.Results().Len())
+	returns := make([]string, 0, sig.Results().Len())
+	err := "nil"
+	for i := 0; i < sig.Results().Len(); i++ {
+		name := fmt.Sprintf("r%d", i)
+		t := sig.Results().At(i).Type()
+		results = append(results, name)
+		returns = append(returns, parser.LocalQualifiedTypeName(t, p.library))
+		if i == sig.Results().Len()-1 && types.Identical(t, types.Universe.Lookup("error").Type()) {
+			err = name
+		}
+	}
+
+	decl := fmt.Sprintf("func (p *%s) %s(%s)", p.TypeName(), m.Name(), strings.Join(params, ", "))
+	switch len(returns) {
+	case 0:
+	case 1:
+		decl += " " + returns[0]
+	default:
+		decl += " (" + strings.Join(returns, ", ") + ")"
+	}
+
+	call := fmt.Sprintf("p.next.%s(%s)", m.Name(), strings.Join(args, ", "))
+	if len(results) > 0 {
+		call = strings.Join(results, ", ") + " := " + call
+	}
+
+	decl += " {\n"
+	if p.beforeHook != nil {
+		decl += fmt.Sprintf("\tif p.c.%s != nil {\n", p.beforeHook.Name())
+		decl += fmt.Sprintf("\t\tp.c.%s(%q, []interface{}{%s})\n", p.beforeHook.Name(), m.Name(), strings.Join(names, ", "))
+		decl += "\t}\n"
+	}
+	if p.hook == nil {
+		if len(results) > 0 {
+			call = "return " + fmt.Sprintf("p.next.%s(%s)", m.Name(), strings.Join(args, ", "))
+		}
+		decl += "\t" + call + "\n"
+		decl += "}\n"
+		return decl
+	}
+	decl += "\tstart := time.Now()\n"
+	decl += "\t" + call + "\n"
+	decl += fmt.Sprintf("\tif p.c.%s != nil {\n", p.hook.Name())
+	decl += fmt.Sprintf("\t\tp.c.%s(%q, []interface{}{%s}, time.Since(start), %s)\n", p.hook.Name(), m.Name(), strings.Join(names, ", "), err)
+	decl += "\t}\n"
+	if len(results) > 0 {
+		decl += "\treturn " + strings.Join(results, ", ") + "\n"
+	}
+	decl += "}\n"
+
+	return decl
+}
+
+func (*ProxyDecl) isTypeDecl() {}
+
+// validateHook validates that 't' is a shape of `func(method string, args []interface{}, duration time.Duration, err error)`.
+func validateHook(t types.Type) error {
+	sig, ok := t.Underlying().(*types.Signature)
+	if ok && sig.Params().Len() == 4 && sig.Results().Len() == 0 && !sig.Variadic() {
+		params := sig.Params()
+		slice, isSlice := params.At(1).Type().(*types.Slice)
+		duration, isNamed := params.At(2).Type().(*types.Named)
+		if types.Identical(params.At(0).Type(), types.Typ[types.String]) &&
+			isSlice && parser.IsEmpty(slice.Elem().Underlying()) &&
+			isNamed && duration.Obj().Pkg() != nil && duration.Obj().Pkg().Path() == "time" && duration.Obj().Name() == "Duration" &&
+			types.Identical(params.At(3).Type(), types.Universe.Lookup("error").Type()) {
+			return nil
+		}
+	}
+	return errors.Errorf(
+		"the hook must be a shape of `func(method string, args []interface{}, duration time.Duration, err error)`, but: %s",
+		parser.TypeNamePrefixedByImportPath(t))
+}
+
+// validateBeforeHook validates that 't' is a shape of `func(method string, args []interface{})`.
+func validateBeforeHook(t types.Type) error {
+	sig, ok := t.Underlying().(*types.Signature)
+	if ok && sig.Params().Len() == 2 && sig.Results().Len() == 0 && !sig.Variadic() {
+		slice, isSlice := sig.Params().At(1).Type().(*types.Slice)
+		if types.Identical(sig.Params().At(0).Type(), types.Typ[types.String]) &&
+			isSlice && parser.IsEmpty(slice.Elem().Underlying()) {
+			return nil
+		}
+	}
+	return errors.Errorf(
+		"the before hook must be a shape of `func(method string, args []interface{})`, but: %s",
+		parser.TypeNamePrefixedByImportPath(t))
+}
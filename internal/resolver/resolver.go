@@ -2,6 +2,8 @@ package resolver
 
 import (
 	"fmt"
+	"go/token"
+	"go/types"
 	"log"
 	"sort"
 	"strings"
@@ -14,8 +16,10 @@ import (
 type Data struct {
 	PublicDecls  map[string][]*FuncData
 	PrivateDecls map[string][]*FuncData
+	TypeDecls    []*TypeData
 	Imports      []string
 	Package      string
+	BuildTags    []string
 }
 
 type FuncData struct {
@@ -27,17 +31,32 @@ type FuncData struct {
 	FuncImpl    string
 }
 
-func Resolve(cache *parser.ObjectCache, target, library string) (*Data, error, []error) {
+type TypeData struct {
+	ImportPaths []string
+	Pkg         string
+	TypeName    string
+	TypeImpl    string
+}
+
+// Resolve resolves the container 'target' in the package 'library' and returns the data for the template.
+// The dependencies of the container must not violate the rules of 'arch', which may be nil.
+func Resolve(cache *parser.ObjectCache, target, library string, arch *parser.Architecture) (*Data, error, []error) {
 	providerImpl, err := loadProviderImpl(cache, library, target)
 	if err != nil {
 		return nil, err, nil
 	}
 
-	resolver := NewResolver(providerImpl, cache, library)
+	resolver, err := NewResolver(providerImpl, cache, library)
+	if err != nil {
+		return nil, err, nil
+	}
 	decls, errs := resolver.Resolve()
 	if errs != nil {
 		return nil, nil, errs
 	}
+	if errs := CheckArchitecture(NewGraph(decls), cache, arch); errs != nil {
+		return nil, nil, errs
+	}
 
 	privates := make(map[string][]*FuncData)
 	publics := make(map[string][]*FuncData)
@@ -52,12 +71,12 @@ func Resolve(cache *parser.ObjectCache, target, library string) (*Data, error, [
 			FuncImpl:    decl.FuncBody(),
 		}
 		switch decl.(type) {
-		case *PublicFuncDecl:
+		case *PublicFuncDecl, *FieldGetterDecl:
 			if _, ok := publics[decl.Pkg()]; !ok {
 				publics[decl.Pkg()] = make([]*FuncData, 0)
 			}
 			publics[decl.Pkg()] = append(publics[decl.Pkg()], data)
-		case *PrivateFuncDecl:
+		case *PrivateFuncDecl, *InstanceFuncDecl:
 			if _, ok := privates[decl.Pkg()]; !ok {
 				privates[decl.Pkg()] = make([]*FuncData, 0)
 			}
@@ -65,6 +84,19 @@ func Resolve(cache *parser.ObjectCache, target, library string) (*Data, error, [
 		}
 	}
 
+	typeDecls := make([]*TypeData, 0)
+	for _, decl := range resolver.TypeDecls() {
+		typeDecls = append(typeDecls, &TypeData{
+			ImportPaths: decl.Imports(),
+			Pkg:         decl.Pkg(),
+			TypeName:    decl.TypeName(),
+			TypeImpl:    decl.TypeBody(),
+		})
+	}
+	sort.SliceStable(typeDecls, func(x, y int) bool {
+		return typeDecls[x].TypeName < typeDecls[y].TypeName
+	})
+
 	for _, slice := range publics {
 		sort.SliceStable(slice, func(x, y int) bool {
 			return slice[x].FuncName < slice[y].FuncName
@@ -85,6 +117,17 @@ func Resolve(cache *parser.ObjectCache, target, library string) (*Data, error, [
 			}
 		}
 	}
+	for _, decl := range typeDecls {
+		for _, path := range decl.ImportPaths {
+			importMap[path] = path
+		}
+	}
+	// The generated code must not import its own package.
+	for path := range importMap {
+		if strings.HasSuffix(path, fmt.Sprintf(" \"%s\"", library)) {
+			delete(importMap, path)
+		}
+	}
 	for _, imp := range importMap {
 		imports = append(imports, imp)
 	}
@@ -99,6 +142,7 @@ func Resolve(cache *parser.ObjectCache, target, library string) (*Data, error, [
 		Imports:      imports,
 		PrivateDecls: privates,
 		PublicDecls:  publics,
+		TypeDecls:    typeDecls,
 	}, nil, nil
 }
 
@@ -117,33 +161,122 @@ func loadProviderImpl(cache *parser.ObjectCache, library, name string) (*parser.
 type Resolver struct {
 	provider *parser.Struct
 
-	fields []*FieldDecl
-	decls  []*PrivateFuncDecl
+	fields  []*FieldDecl
+	getters []*FieldGetterDecl
+	decls   []*PrivateFuncDecl
+
+	bindings map[*parser.Iface]constructor
+
+	// injectables are the structs marked as `provider:inject`.
+	injectables []*structConstructor
+
+	// hook is the field of the provider tagged with `blueprinter:"hook"`.
+	hook *parser.Field
+	// beforeHook is the field of the provider tagged with `blueprinter:"before_hook"`.
+	beforeHook *parser.Field
+	// proxies are the proxies for the interfaces marked as `provider:proxy`.
+	// The key is the string representation of the interface returned by Iface.String().
+	proxies map[string]*ProxyDecl
 
-	bindings map[*parser.Iface]*parser.Func
+	// instances are the derivations for instantiations of generic types.
+	// The key is the string representation of the instantiated type.
+	instances map[string]*InstanceFuncDecl
+	// instanceKeys are the keys of instances in the order they are built,
+	// to discard the ones built for a constructor which fails to be resolved.
+	instanceKeys []string
+	// instantiating is the set of the keys of instances being built, to detect circular dependencies.
+	instantiating map[string]bool
+	// typesContext de-duplicates the instantiations of generic functions within the resolver.
+	typesContext *types.Context
+
+	// decorators are the functions marked as `provider:decorate` for each interface, sorted by their order.
+	// The key is the string representation of the interface returned by Iface.String().
+	decorators map[string][]*decorator
+
+	// wireSets are the provider sets of google/wire given by `blueprinter:wire`.
+	wireSets []*parser.WireSet
+	// wired is the set of the functions listed in wireSets, which are bindable without params.
+	// The key is the string representation of the function returned by Func.String().
+	wired map[string]bool
+
+	// roots is true if any root is given by `provider:root` or `blueprinter:roots`.
+	// Only the roots are exposed, and the derivations unreachable from them are pruned.
+	roots bool
 
 	cache   *parser.ObjectCache
 	library string
 }
 
-func NewResolver(provider *parser.Struct, cache *parser.ObjectCache, library string) *Resolver {
+// NewResolver returns a Resolver for the container struct 'provider'.
+// The fields of 'provider' are used as derivations according to their `blueprinter` struct tags.
+func NewResolver(provider *parser.Struct, cache *parser.ObjectCache, library string) (*Resolver, error) {
 	fields := make([]*FieldDecl, 0)
+	getters := make([]*FieldGetterDecl, 0)
+	var hook, beforeHook *parser.Field
 
-	for i := 0; i < provider.Type().NumFields(); i++ {
-		f := provider.Type().Field(i)
-		fields = append(fields, &FieldDecl{
-			Name: f.Name(),
-			Type: f.Type(),
-		})
+	providerFields, err := provider.Fields()
+	if err != nil {
+		return nil, err
 	}
 
-	return &Resolver{
-		provider: provider,
-		fields:   fields,
-		decls:    make([]*PrivateFuncDecl, 0),
-		cache:    cache,
-		library:  library,
+	for _, f := range providerFields {
+		if f.Ignored() {
+			continue
+		}
+		if f.Hook() {
+			if hook != nil {
+				return nil, errors.Errorf("%s has more than one field tagged with `hook`", provider.String())
+			}
+			if err := validateHook(f.Type()); err != nil {
+				return nil, errors.Wrapf(err, "%s.%s", provider.String(), f.Name())
+			}
+			hook = f
+			continue
+		}
+		if f.BeforeHook() {
+			if beforeHook != nil {
+				return nil, errors.Errorf("%s has more than one field tagged with `before_hook`", provider.String())
+			}
+			if err := validateBeforeHook(f.Type()); err != nil {
+				return nil, errors.Wrapf(err, "%s.%s", provider.String(), f.Name())
+			}
+			beforeHook = f
+			continue
+		}
+		field := &FieldDecl{
+			Name:      f.Name(),
+			Type:      f.Type(),
+			Qualifier: f.Qualifier(),
+		}
+		fields = append(fields, field)
+
+		if f.Exposed() {
+			// An exported field is accessible without a getter, and the getter would conflict with it.
+			if f.Exported() {
+				return nil, errors.Errorf("%s.%s is exported and cannot be tagged with `expose`", provider.String(), f.Name())
+			}
+			getters = append(getters, &FieldGetterDecl{
+				field:   field,
+				library: library,
+			})
+		}
 	}
+
+	return &Resolver{
+		provider:   provider,
+		fields:     fields,
+		getters:    getters,
+		hook:       hook,
+		beforeHook: beforeHook,
+		decls:      make([]*PrivateFuncDecl, 0),
+		cache:      cache,
+		library:    library,
+
+		bindings:      make(map[*parser.Iface]constructor),
+		instances:     make(map[string]*InstanceFuncDecl),
+		instantiating: make(map[string]bool),
+		typesContext:  types.NewContext(),
+	}, nil
 }
 
 // Resolve feature searches for constructors within the ObjectCache that can resolve dependencies, and returns the resolved results as function declarations (FuncDecl).
@@ -158,16 +291,46 @@ func NewResolver(provider *parser.Struct, cache *parser.ObjectCache, library str
 // 3. For all functions in the ObjectCache, determine their resolution results.
 //
 // Through this process, we can provide a simple and user-friendly interface with resolved dependencies.
+// If the steps 2 and 3 fail, the declarations resolved in spite of the errors are returned with them.
 func (r *Resolver) Resolve() ([]FuncDecl, []error) {
 	resolved := make([]FuncDecl, 0)
 
+	for _, getter := range r.getters {
+		resolved = append(resolved, getter)
+	}
+
+	if errs := r.setupInjectables(); len(errs) > 0 {
+		return nil, errs
+	}
+	if errs := r.setupWireSets(); len(errs) > 0 {
+		return nil, errs
+	}
+
+	if errs := r.setupDecorators(); len(errs) > 0 {
+		return nil, errs
+	}
+
+	if errs := r.setupProxies(); len(errs) > 0 {
+		return nil, errs
+	}
+
 	// Step 1: Build bindings for all interfaces in the ObjectCache.
+	// Bindings given by blueprint.Bind and wire.Bind take precedence over the others.
+	if errs := r.setupMarkerBindings(); len(errs) > 0 {
+		return nil, errs
+	}
+	if errs := r.setupWireBindings(); len(errs) > 0 {
+		return nil, errs
+	}
 	if errs := r.setupBindings(); len(errs) > 0 {
 		return nil, errs
 	}
+	if errs := r.setupExternalBindings(); len(errs) > 0 {
+		return nil, errs
+	}
 
 	// Step 2: Derive constructors for all interfaces.
-	r.deriveConstructorsForEachInterfaces()
+	deriveErrs := r.deriveConstructorsForEachInterfaces()
 	for _, decl := range r.decls {
 		resolved = append(resolved, decl)
 	}
@@ -175,37 +338,96 @@ func (r *Resolver) Resolve() ([]FuncDecl, []error) {
 	// Step 3: For all constructors, find their resolution results.
 	// Note that the resolution results are the union of all methods found here and all derivations generated in Step 2.
 	decls, errs := r.resolveEachConstructorsPresumingDerivationIsDone()
-	if len(errs) > 0 {
-		return nil, errs
+	if len(deriveErrs) > 0 {
+		// The errors in Step 3 may be caused by the ones in Step 2, which are reported instead.
+		errs = deriveErrs
 	}
 	for _, decl := range decls {
 		resolved = append(resolved, decl)
 	}
 
+	// Instantiations of generic types are built on demand while finding derivations in the steps above.
+	for _, decl := range r.instances {
+		resolved = append(resolved, decl)
+	}
+
+	if len(errs) > 0 {
+		// The decls resolved in spite of the errors are returned with them, so that the dependencies found so far can be checked.
+		return resolved, errs
+	}
+
+	if r.roots {
+		resolved = r.prune(resolved)
+	}
+
 	return resolved, nil
 }
 
+// A MustResolveError is an error of a constructor marked as `provider:must_resolve`,
+// or declared in a package in the strict mode, which cannot be resolved.
+type MustResolveError struct {
+	// Pkg and Name are the import path and the name of the constructor.
+	Pkg  string
+	Name string
+	// Strict is true if the constructor must be resolved because of the strict mode.
+	Strict bool
+	// Pos is the position of the constructor, which is invalid if unknown.
+	Pos token.Position
+	Err error
+}
+
+func (e *MustResolveError) Error() string {
+	if e.Strict {
+		return fmt.Sprintf("unable to resolve %s.%s, which must be resolved in the strict mode: %s", e.Pkg, e.Name, e.Err.Error())
+	}
+	return fmt.Sprintf("unable to resolve %s.%s, which is marked as `must_resolve`: %s", e.Pkg, e.Name, e.Err.Error())
+}
+
+func (e *MustResolveError) Unwrap() error {
+	return e.Err
+}
+
 func (r *Resolver) resolveEachConstructorsPresumingDerivationIsDone() ([]*PublicFuncDecl, []error) {
 	resolved := make([]*PublicFuncDecl, 0)
 	errs := make([]error, 0)
-	for _, fn := range r.cache.Funcs() {
-		if fn.ImportPath() == r.library {
-			continue
+
+	fns := r.constructors()
+
+	listed := make(map[string]bool)
+	for _, name := range r.provider.Roots() {
+		listed[name] = false
+	}
+	r.roots = len(listed) > 0
+	for _, fn := range fns {
+		r.roots = r.roots || fn.IsRoot()
+	}
+
+	for _, fn := range fns {
+		_, isListed := listed[fn.String()]
+		root := fn.IsRoot() || isListed
+		if isListed {
+			listed[fn.String()] = true
 		}
-		if !fn.ShouldTryToResolve() {
+		// Constructors other than the roots are resolved only to check `provider:must_resolve`.
+		if r.roots && !root && !fn.MustBeResolved() {
 			continue
 		}
-		params, err := r.findDerivationsForParams(fn)
+
+		params, err := r.findDerivationsForParams(fn, true)
 		if err != nil {
-			if fn.MustBeResolved() {
-				errs = append(errs, fmt.Errorf(
-					"unable to resolve %s.%s, which is marked as `must_resolve`: %s",
-					fn.ImportPath(), fn.Name(), err.Error(),
-				))
+			switch {
+			case fn.MustBeResolved():
+				errs = append(errs, &MustResolveError{Pkg: fn.ImportPath(), Name: fn.Name(), Strict: fn.IsStrict(), Pos: positionOf(fn), Err: err})
+			case root:
+				errs = append(errs, &RootError{Root: fn.String(), Pos: positionOf(fn), Err: err})
 			}
 			continue
 		}
+		if r.roots && !root {
+			continue
+		}
 
+		reportEmptyOptionals(fn, params)
 		decl := &PublicFuncDecl{
 			fn:      fn,
 			library: r.library,
@@ -214,16 +436,85 @@ func (r *Resolver) resolveEachConstructorsPresumingDerivationIsDone() ([]*Public
 		resolved = append(resolved, decl)
 	}
 
+	names := make([]string, 0, len(listed))
+	for name := range listed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !listed[name] {
+			errs = append(errs, errors.Errorf("the root %s given by `%s` on %s is not a constructor in the scanned packages", name, parser.DirectiveRoots, r.provider.String()))
+		}
+	}
+	errs = append(errs, r.invalidRootErrors()...)
+
 	if len(errs) > 0 {
-		return nil, errs
+		return resolved, errs
 	}
 
 	return resolved, nil
 }
 
-func (r *Resolver) deriveConstructorsForEachInterfaces() {
+// invalidRootErrors returns the errors of the functions marked as `provider:root` which are not constructors,
+// which would prune every constructor without a public method.
+func (r *Resolver) invalidRootErrors() []error {
+	errs := make([]error, 0)
+	for _, fn := range r.cache.Funcs() {
+		if !fn.IsRoot() || fn.ImportPath() == r.library || fn.ShouldTryToResolve() {
+			continue
+		}
+		var why string
+		switch {
+		case fn.IsExcluded():
+			why = "it is excluded"
+		case fn.IsDecorator():
+			why = "it is a decorator"
+		case fn.IsGeneric():
+			why = "it is generic"
+		default:
+			why = "it does not return a single value"
+		}
+		errs = append(errs, &RootError{Root: fn.String(), Pos: fn.Position(), Err: errors.Errorf("not a constructor, since %s", why)})
+	}
+	return errs
+}
+
+// constructors returns the constructors which get public methods unless the roots are given.
+func (r *Resolver) constructors() []constructor {
+	fns := make([]constructor, 0)
+	for _, fn := range r.cache.Funcs() {
+		if fn.ImportPath() == r.library {
+			continue
+		}
+		if !fn.ShouldTryToResolve() {
+			continue
+		}
+		fns = append(fns, &funcConstructor{fn})
+	}
+	for _, st := range r.injectables {
+		fns = append(fns, st)
+	}
+	return fns
+}
+
+// deriveConstructorsForEachInterfaces derives the bound constructors of the interfaces until no more can be derived.
+// It returns the errors of the decorators which cannot be applied to the constructors derived.
+func (r *Resolver) deriveConstructorsForEachInterfaces() []error {
 	derived := make(map[*parser.Iface]*PrivateFuncDecl)
 
+	ifaces := make([]*parser.Iface, 0, len(r.bindings))
+	for iface := range r.bindings {
+		ifaces = append(ifaces, iface)
+	}
+	sort.SliceStable(ifaces, func(x, y int) bool {
+		return ifaces[x].String() < ifaces[y].String()
+	})
+
+	// When nothing can be derived, optional params are allowed to be left empty.
+	// Only one interface is derived in such a case, because the optional params of the others
+	// may be derived from it in the next loop.
+	lenient := false
+
 	for loop := 0; ; loop++ {
 		// If the number of loops exceeds 1000, it's likely that an infinite loop has occurred.
 		if loop > 1000 {
@@ -231,43 +522,201 @@ func (r *Resolver) deriveConstructorsForEachInterfaces() {
 		}
 
 		numDerived := 0
-		for iface, fn := range r.bindings {
+		for _, iface := range ifaces {
+			fn := r.bindings[iface]
+
 			// Check if the interface has already been derived. If so, skip it.
 			if _, ok := derived[iface]; ok {
 				continue
 			}
 
 			// Check if all arguments of the function are derived. If not, skip it.
-			params, err := r.findDerivationsForParams(fn)
+			params, err := r.findDerivationsForParams(fn, lenient)
+			if err != nil {
+				continue
+			}
+
+			// Check if all arguments of the decorators are derived. If not, skip it.
+			decorations, err := r.findDecorations(iface, lenient)
 			if err != nil {
 				continue
 			}
 
+			reportEmptyOptionals(fn, params)
+			for _, d := range decorations {
+				reportEmptyOptionals(d.fn, d.params)
+			}
+
 			decl := &PrivateFuncDecl{
-				library: r.library,
-				fn:      fn,
-				iface:   iface,
-				params:  params,
+				library:     r.library,
+				fn:          fn,
+				iface:       iface,
+				params:      params,
+				decorations: decorations,
+				proxy:       r.proxies[iface.String()],
 			}
 			numDerived += 1
 			r.AddFunc(decl)
 			derived[iface] = decl
+
+			if lenient {
+				break
+			}
 		}
 
 		if numDerived == 0 {
-			return
+			if lenient {
+				return r.decorationErrors(ifaces, derived)
+			}
+			lenient = true
+			continue
+		}
+		lenient = false
+	}
+}
+
+// decorationErrors returns the errors of the interfaces which are not derived only because of their decorators,
+// since they would be reported as unrelated errors of their consumers otherwise.
+func (r *Resolver) decorationErrors(ifaces []*parser.Iface, derived map[*parser.Iface]*PrivateFuncDecl) []error {
+	errs := make([]error, 0)
+	for _, iface := range ifaces {
+		if _, ok := derived[iface]; ok {
+			continue
+		}
+		if _, err := r.findDerivationsForParams(r.bindings[iface], true); err != nil {
+			continue
+		}
+		if _, err := r.findDecorations(iface, true); err != nil {
+			errs = append(errs, errors.Wrapf(err, "unable to decorate %s", iface.String()))
 		}
 	}
+	return errs
 }
 
 func (w *Resolver) AddFunc(fn *PrivateFuncDecl) {
 	w.decls = append(w.decls, fn)
 }
 
+// setupInjectables collects the structs marked as `provider:inject` in the ObjectCache.
+func (r *Resolver) setupInjectables() []error {
+	r.injectables = make([]*structConstructor, 0)
+	errs := make([]error, 0)
+
+	for _, st := range r.cache.Structs() {
+		if st.ImportPath() == r.library {
+			continue
+		}
+		if !st.Exported() || st.IsExcluded() || st.IsGeneric() || !st.IsInjectable() {
+			continue
+		}
+		c, err := newStructConstructor(st)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		r.injectables = append(r.injectables, c)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// setupDecorators collects the functions marked as `provider:decorate` in the ObjectCache.
+func (r *Resolver) setupDecorators() []error {
+	r.decorators = make(map[string][]*decorator)
+	errs := make([]error, 0)
+
+	ifaces := r.cache.Ifaces()
+	for _, fn := range r.cache.Funcs() {
+		if fn.IsExcluded() || !fn.IsDecorator() {
+			continue
+		}
+		d, err := newDecorator(fn, ifaces)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		r.decorators[d.iface.String()] = append(r.decorators[d.iface.String()], d)
+	}
+
+	for _, decorators := range r.decorators {
+		sort.SliceStable(decorators, func(x, y int) bool {
+			if decorators[x].order != decorators[y].order {
+				return decorators[x].order < decorators[y].order
+			}
+			return decorators[x].String() < decorators[y].String()
+		})
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// setupProxies builds proxies for the interfaces marked as `provider:proxy` in the ObjectCache.
+func (r *Resolver) setupProxies() []error {
+	r.proxies = make(map[string]*ProxyDecl)
+	errs := make([]error, 0)
+
+	for _, iface := range r.cache.Ifaces() {
+		if iface.IsExcluded() || iface.IsGeneric() || !iface.IsProxied() {
+			continue
+		}
+		proxy, err := newProxyDecl(iface, r.hook, r.beforeHook, r.provider.Name(), r.library)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		r.proxies[iface.String()] = proxy
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// TypeDecls returns the types to be declared in the generated code.
+// It must be called after Resolve.
+func (r *Resolver) TypeDecls() []TypeDecl {
+	decls := make([]TypeDecl, 0)
+	for _, decl := range r.decls {
+		if decl.proxy != nil {
+			decls = append(decls, decl.proxy)
+		}
+	}
+	return decls
+}
+
+// findDecorations finds derivations for the params of all decorators of 'iface'.
+func (r *Resolver) findDecorations(iface *parser.Iface, lenient bool) ([]*decoration, error) {
+	decorations := make([]*decoration, 0)
+	for _, d := range r.decorators[iface.String()] {
+		params, err := r.findDerivationsForParams(d, lenient)
+		if err != nil {
+			return nil, err
+		}
+		decorations = append(decorations, &decoration{fn: d, params: params})
+	}
+	return decorations, nil
+}
+
+// findInjectable returns the struct marked as `provider:inject` which is declared as 'pkg.name'.
+func (r *Resolver) findInjectable(pkg, name string) (*structConstructor, bool) {
+	for _, st := range r.injectables {
+		if st.ImportPath() == pkg && st.Name() == name {
+			return st, true
+		}
+	}
+	return nil, false
+}
+
 // setupBindings は、 r の持つ ObjectCache 内のすべてのインターフェースに対する binding を構築します。
 // bindings については、 Resolver 型内のコメントを参照してください。
 func (r *Resolver) setupBindings() []error {
-	r.bindings = make(map[*parser.Iface]*parser.Func, 0)
 	errs := make([]error, 0)
 
 	for _, iface := range r.cache.Ifaces() {
@@ -275,6 +724,10 @@ func (r *Resolver) setupBindings() []error {
 		if iface.IsExcluded() {
 			continue
 		}
+		// In the case where 'blueprint.Bind' or 'wire.Bind' is given, skip it.
+		if _, ok := r.explicitlyBound(iface); ok {
+			continue
+		}
 		// In the case of 'interface{}', skip it.
 		if iface.Interface().Empty() {
 			continue
@@ -283,23 +736,34 @@ func (r *Resolver) setupBindings() []error {
 		if !iface.Exported() {
 			continue
 		}
+		// In the case of generic interfaces, skip it. They are bound after instantiation.
+		if iface.IsGeneric() {
+			continue
+		}
 
-		// In the case where 'provider:resolve' is specified
-		if iface.IsResolve() {
-			pkg, name, err := iface.ResolvedPkgAndFuncName()
+		// In the case where 'provider:switch' is specified
+		if iface.IsSwitch() {
+			if iface.IsResolve() {
+				errs = append(errs, errors.Errorf("%s cannot have both `provider:switch` and `provider:resolve`", iface.String()))
+				continue
+			}
+			fn, err := r.switchConstructor(iface)
 			if err != nil {
 				errs = append(errs, err)
 				continue
 			}
-
-			obj, ok := r.cache.Get(pkg, name)
-			if !ok {
-				errs = append(errs, errors.Errorf("%s does not found %s.%s", iface.Name(), pkg, name))
+			r.bindings[iface] = fn
+		} else if iface.IsResolve() {
+			// In the case where 'provider:resolve' is specified
+			pkg, name, err := iface.ResolvedPkgAndFuncName()
+			if err != nil {
+				errs = append(errs, err)
 				continue
 			}
-			fn, ok := obj.Func()
-			if !ok {
-				errs = append(errs, errors.Errorf("%s.%s is not a function: %s", pkg, name, iface.Name()))
+
+			fn, err := r.lookupConstructor(iface, pkg, name)
+			if err != nil {
+				errs = append(errs, err)
 				continue
 			}
 			r.bindings[iface] = fn
@@ -309,8 +773,15 @@ func (r *Resolver) setupBindings() []error {
 				continue
 			}
 
+			// If multiple Types implementing 'iface' exist, prefer the ones marked as 'provider:primary'.
+			if len(typs) > 1 {
+				if primaries := r.primaryTypes(typs); len(primaries) > 0 {
+					typs = primaries
+				}
+			}
+
 			// If multiple Types implementing 'iface' exist, it's necessary to either
-			// narrow down the candidates with 'provider:resolve' or exclude unwanted
+			// narrow down the candidates with 'provider:resolve' or 'provider:primary', or exclude unwanted
 			// candidates with 'provider:exclude'. In this case, since neither applies,
 			// it's not possible to uniquely identify the binding target, resulting in an error.
 			if len(typs) != 1 {
@@ -318,39 +789,43 @@ func (r *Resolver) setupBindings() []error {
 					"Unable to determine an implementation for %s.%s: "+
 						"more than one parser implement this interface.\n"+
 						"Use // provider:resolve to specify which constructor should be used, "+
+						"// provider:primary to mark the default implementation, "+
 						"or // provider:exclude if you want to ignore certain constructors for this type.\n\n"+
 						"Possible implementations for this interface are:\n",
 					iface.ImportPath(), iface.Name())
+				candidates := make([]*Candidate, 0, len(typs))
 				for i, t := range typs {
 					msg += fmt.Sprintf("\t%d: %s\n", i, parser.QualifiedTypeName(t))
+					candidates = append(candidates, &Candidate{Name: parser.TypeNamePrefixedByImportPath(t), Pos: r.typePosition(t)})
 				}
-				errs = append(errs, errors.New(msg))
+				errs = append(errs, &AmbiguousError{Iface: iface.String(), Pos: iface.Position(), Candidates: candidates, msg: msg})
 				continue
 			}
 
 			t := typs[0]
 
-			fns := make([]*parser.Func, 0)
-			for _, fn := range r.cache.Funcs() {
-				if !fn.IsBindable() {
-					continue
-				}
-				if parser.IsEmpty(t) {
-					continue
-				}
-				if !parser.Identical(fn.Results().At(0).Type(), t) {
-					continue
-				}
-				fns = append(fns, fn)
-			}
+			fns := r.constructorsFor(t)
 			// skip if not found
 			if len(fns) == 0 {
 				log.Printf("skip(can not find resolver function): %v\n", typs[0])
 				continue
 			}
 
+			// If multiple functions can be bound to 'iface', prefer the ones marked as 'provider:primary'.
+			if len(fns) > 1 {
+				primaries := make([]constructor, 0)
+				for _, fn := range fns {
+					if fn.IsPrimary() {
+						primaries = append(primaries, fn)
+					}
+				}
+				if len(primaries) > 0 {
+					fns = primaries
+				}
+			}
+
 			// If multiple functions can be bound to 'iface', it's necessary to either
-			// narrow down the candidates with 'provider:resolve' or exclude unwanted
+			// narrow down the candidates with 'provider:resolve' or 'provider:primary', or exclude unwanted
 			// candidates with 'provider:exclude'. In this case, since neither applies,
 			// it's not possible to uniquely identify the binding target, resulting in an error.
 			if len(fns) != 1 {
@@ -358,13 +833,16 @@ func (r *Resolver) setupBindings() []error {
 					"Unable to determine constructors for %s.%s (which is resolved to %s): "+
 						"More than one constructors are found for this interface\n"+
 						"Use // provider:resolve to specify which constructor should be used, "+
+						"// provider:primary to mark the default constructor, "+
 						"or // provider:exclude if you want to ignore certain constructors for this type.\n\n"+
 						"Possible constructors for this interface are:\n",
 					iface.ImportPath(), iface.Name(), parser.QualifiedTypeName(t))
+				candidates := make([]*Candidate, 0, len(fns))
 				for i, fn := range fns {
-					msg += fmt.Sprintf("\t%d: %s\n", i, parser.QualifiedTypeName(fn.Type()))
+					msg += fmt.Sprintf("\t%d: %s\n", i, fn.String())
+					candidates = append(candidates, &Candidate{Name: fn.String(), Pos: positionOf(fn)})
 				}
-				errs = append(errs, errors.New(msg))
+				errs = append(errs, &AmbiguousError{Iface: iface.String(), Pos: iface.Position(), Candidates: candidates, msg: msg})
 				continue
 			}
 			r.bindings[iface] = fns[0]
@@ -376,35 +854,266 @@ func (r *Resolver) setupBindings() []error {
 	return errs
 }
 
-func (r *Resolver) findDerivationsForParams(fn *parser.Func) ([]Derivation, error) {
+// setupExternalBindings builds bindings for the interfaces declared outside the scanned packages,
+// such as 'io.Writer', which are specified by `provider:bind` on implementations or constructors.
+func (r *Resolver) setupExternalBindings() []error {
+	errs := make([]error, 0)
+	bound := make(map[string]constructor)
+
+	for _, obj := range r.cache.All() {
+		if obj.IsExcluded() {
+			continue
+		}
+		for _, name := range obj.BoundIfaces() {
+			iface, err := r.cache.LookupIface(name)
+			if err != nil {
+				errs = append(errs, errors.Wrapf(err, "%s", obj.String()))
+				continue
+			}
+			if _, ok := r.cache.Get(iface.ImportPath(), iface.Name()); ok {
+				errs = append(errs, errors.Errorf(
+					"%s: %s is declared in the scanned packages, use `provider:resolve` or `provider:primary` instead of `provider:bind`",
+					obj.String(), name))
+				continue
+			}
+
+			fn, err := r.boundConstructor(obj, iface)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if prev, ok := bound[iface.String()]; ok {
+				errs = append(errs, errors.Errorf("%s is bound to both %s and %s", iface.String(), prev.String(), fn.String()))
+				continue
+			}
+			if prev, ok := r.explicitlyBound(iface); ok {
+				errs = append(errs, errors.Errorf("%s is bound to %s by blueprint.Bind or wire.Bind, and cannot be bound to %s", iface.String(), prev.String(), fn.String()))
+				continue
+			}
+			bound[iface.String()] = fn
+			r.bindings[iface] = fn
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// boundConstructor returns the constructor for 'iface' specified by `provider:bind` on 'obj',
+// which is a constructor or an implementation type.
+func (r *Resolver) boundConstructor(obj *parser.Object, iface *parser.Iface) (constructor, error) {
+	fns := make([]constructor, 0)
+	if fn, ok := obj.Func(); ok {
+		if !r.isBindable(fn) {
+			return nil, errors.Errorf("%s can not be bound to %s: it is not a constructor", obj.String(), iface.String())
+		}
+		fns = append(fns, &funcConstructor{fn})
+	} else if _, ok := obj.Struct(); ok {
+		seen := make(map[string]bool)
+		for _, t := range []parser.Type{obj.Type(), types.NewPointer(obj.Type())} {
+			for _, fn := range r.constructorsFor(t) {
+				if !seen[fn.String()] {
+					seen[fn.String()] = true
+					fns = append(fns, fn)
+				}
+			}
+		}
+	} else {
+		return nil, errors.Errorf("%s can not be bound to %s: it is neither a struct nor a function", obj.String(), iface.String())
+	}
+
+	// If multiple functions are found, prefer the ones marked as 'provider:primary'.
+	if len(fns) > 1 {
+		primaries := make([]constructor, 0)
+		for _, fn := range fns {
+			if fn.IsPrimary() {
+				primaries = append(primaries, fn)
+			}
+		}
+		if len(primaries) > 0 {
+			fns = primaries
+		}
+	}
+	if len(fns) != 1 {
+		names := make([]string, 0, len(fns))
+		for _, fn := range fns {
+			names = append(names, fn.String())
+		}
+		return nil, errors.Errorf("unable to determine a constructor of %s for %s: [%s]", obj.String(), iface.String(), strings.Join(names, ", "))
+	}
+	if !types.AssignableTo(fns[0].result(), iface.Type()) {
+		return nil, errors.Errorf("%s does not implement %s", fns[0].String(), iface.String())
+	}
+
+	return fns[0], nil
+}
+
+// lookupConstructor returns the constructor declared as 'pkg.name', which is specified for 'iface'.
+func (r *Resolver) lookupConstructor(iface *parser.Iface, pkg, name string) (constructor, error) {
+	if st, ok := r.findInjectable(pkg, name); ok {
+		return st, nil
+	}
+
+	obj, ok := r.cache.Get(pkg, name)
+	if !ok {
+		return nil, errors.Errorf("%s does not found %s.%s", iface.Name(), pkg, name)
+	}
+	fn, ok := obj.Func()
+	if !ok {
+		return nil, errors.Errorf("%s.%s is not a function: %s", pkg, name, iface.Name())
+	}
+	return &funcConstructor{fn}, nil
+}
+
+// switchConstructor returns the constructor for 'iface' marked as `provider:switch`.
+// The selector must be a field or a method without params of the container.
+func (r *Resolver) switchConstructor(iface *parser.Iface) (constructor, error) {
+	selector, cases, err := iface.Switch()
+	if err != nil {
+		return nil, err
+	}
+
+	expr := ""
+	var selectorType types.Type
+	for i := 0; i < r.provider.Type().NumFields(); i++ {
+		if f := r.provider.Type().Field(i); f.Name() == selector {
+			expr = "f." + selector
+			selectorType = f.Type()
+		}
+	}
+	pkg := r.provider.Object.Type().(*types.Named).Obj().Pkg()
+	if expr == "" {
+		mset := types.NewMethodSet(types.NewPointer(r.provider.Object.Type()))
+		if sel := mset.Lookup(pkg, selector); sel != nil {
+			sig := sel.Type().(*types.Signature)
+			if sig.Params().Len() != 0 || sig.Results().Len() != 1 {
+				return nil, &parser.DirectiveError{Pos: iface.SwitchPos(), Err: errors.Errorf("%s: the selector %s.%s must have no params and return a single value", iface.String(), r.provider.Name(), selector)}
+			}
+			expr = "f." + selector + "()"
+			selectorType = sig.Results().At(0).Type()
+		}
+	}
+	if expr == "" {
+		return nil, &parser.DirectiveError{Pos: iface.SwitchPos(), Err: errors.Errorf("%s: the selector %s is neither a field nor a method of %s", iface.String(), selector, r.provider.Name())}
+	}
+
+	candidates := make([]*switchCase, 0, len(cases))
+	for _, c := range cases {
+		fn, err := r.lookupConstructor(iface, c.Pkg, c.FuncName)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, &switchCase{value: c.Value, fn: fn, pos: c.Pos})
+	}
+
+	return newSwitchConstructor(iface, expr, selectorType, pkg, candidates)
+}
+
+// reportEmptyOptionals reports the optional params of 'fn' which are left empty.
+func reportEmptyOptionals(fn constructor, params []Derivation) {
+	for _, param := range params {
+		if z, ok := param.(*ZeroDecl); ok {
+			log.Printf("optional param %s (%s) of %s is left empty: no derivations found\n",
+				z.Name, parser.TypeNamePrefixedByImportPath(z.Type), fn.String())
+		}
+	}
+}
+
+// constructorsFor returns the constructors which can build a value of the implementation type 't'.
+func (r *Resolver) constructorsFor(t parser.Type) []constructor {
+	fns := make([]constructor, 0)
+	if parser.IsEmpty(t) {
+		return fns
+	}
+	for _, fn := range r.cache.Funcs() {
+		if !r.isBindable(fn) {
+			continue
+		}
+		if !parser.Identical(fn.Results().At(0).Type(), t) {
+			continue
+		}
+		fns = append(fns, &funcConstructor{fn})
+	}
+	for _, st := range r.injectables {
+		// A composite literal of the struct can be bound regardless of whether
+		// the struct or the pointer to it implements the interface.
+		if !parser.Identical(st.result(), t) && !parser.Identical(st.Object.Type(), t) {
+			continue
+		}
+		fns = append(fns, st)
+	}
+	return fns
+}
+
+// primaryTypes returns the implementation types in 'typs' which are marked as `provider:primary`,
+// or which have a constructor marked as `provider:primary`.
+func (r *Resolver) primaryTypes(typs []parser.Type) []parser.Type {
+	primaries := make([]parser.Type, 0)
+	for _, t := range typs {
+		if obj, ok := r.cache.ObjectOf(t); ok && obj.IsPrimary() {
+			primaries = append(primaries, t)
+			continue
+		}
+		for _, fn := range r.constructorsFor(t) {
+			if fn.IsPrimary() {
+				primaries = append(primaries, t)
+				break
+			}
+		}
+	}
+	return primaries
+}
+
+// findDerivationsForParams finds derivations for all params of 'fn'.
+// If 'lenient' is true, optional params without derivations are passed the zero value.
+func (r *Resolver) findDerivationsForParams(fn constructor, lenient bool) ([]Derivation, error) {
 	errs := make([]error, 0)
 	params := make([]Derivation, 0)
-	for i := 0; i < fn.Params().Len(); i++ {
-		t := fn.Params().At(i).Type()
-		f, err := r.findDerivation(t)
+	numInstances := len(r.instanceKeys)
+	for i, param := range fn.params() {
+		f, err := r.findDerivation(param.Name(), param.Type(), lenient)
 		if err != nil {
+			if lenient && fn.optional(i) {
+				params = append(params, &ZeroDecl{Name: param.Name(), Type: param.Type(), library: r.library})
+				continue
+			}
 			errs = append(errs, err)
 			continue
 		}
 		params = append(params, f)
 	}
 	if len(errs) > 0 {
+		// The instances are kept only if their consumer is resolved.
+		r.discardInstances(numInstances)
 		errMsg := fmt.Sprintf("unable to derive parameters for the function %s.%s\n",
 			fn.ImportPath(), fn.Name())
 		for _, e := range errs {
 			errMsg += fmt.Sprintf("\t%s\n", e.Error())
 		}
-		return nil, errors.New(errMsg)
+		return nil, &ParamsError{Constructor: fn.String(), Pos: positionOf(fn), Errs: errs, msg: errMsg}
 	}
 	return params, nil
 }
 
-func (w *Resolver) findDerivation(t parser.Type) (Derivation, error) {
+// findDerivation finds a derivation for the param 'name' of type 't'.
+// Fields qualified with 'name' take precedence over unqualified fields.
+// 'lenient' is passed to the params of the generic constructors instantiated for 't'.
+func (w *Resolver) findDerivation(name string, t parser.Type, lenient bool) (Derivation, error) {
 	// unsupported to struct{}, interface{}
 	if parser.IsEmpty(t) {
 		return nil, fmt.Errorf("the type %s is empty", parser.TypeNamePrefixedByImportPath(t))
 	}
 	for _, f := range w.fields {
+		if f.Qualifier != "" && f.Qualifier == name && parser.AssignableTo(f.Type, t) {
+			return f, nil
+		}
+	}
+	for _, f := range w.fields {
+		if f.Qualifier != "" {
+			continue
+		}
 		// NOTE: If the field is an interface and multiple fields satisfy 't', it might pass an unexpected one.
 		// Currently, no workaround comes to mind and it's not a concern for now, so let's set it aside for the moment.
 		if parser.AssignableTo(f.Type, t) {
@@ -419,5 +1128,17 @@ func (w *Resolver) findDerivation(t parser.Type) (Derivation, error) {
 		}
 	}
 
+	// Instantiate generic constructors if 't' is an instantiation of a generic type, like 'Repository[User]'.
+	decl, err := w.findInstance(t, lenient)
+	if err != nil {
+		return nil, err
+	}
+	if decl != nil {
+		return decl, nil
+	}
+
+	if hint := w.wireHint(t); hint != "" {
+		return nil, fmt.Errorf("no derivations found for %s: %s", parser.TypeNamePrefixedByImportPath(t), hint)
+	}
 	return nil, fmt.Errorf("no derivations found for %s", parser.TypeNamePrefixedByImportPath(t))
 }
@@ -0,0 +1,56 @@
+package resolver
+
+// prune returns 'decls' without the derivations unreachable from the public methods,
+// and drops them from the derivations of the resolver as well, so that their proxies are not generated.
+func (r *Resolver) prune(decls []FuncDecl) []FuncDecl {
+	reached := make(map[Derivation]bool)
+	var visit func(params []Derivation)
+	visit = func(params []Derivation) {
+		for _, param := range params {
+			if reached[param] {
+				continue
+			}
+			switch d := param.(type) {
+			case *PrivateFuncDecl:
+				reached[d] = true
+				visit(d.params)
+				for _, dec := range d.decorations {
+					visit(dec.params)
+				}
+			case *InstanceFuncDecl:
+				reached[d] = true
+				visit(d.params)
+			}
+		}
+	}
+	for _, decl := range decls {
+		if d, ok := decl.(*PublicFuncDecl); ok {
+			visit(d.params)
+		}
+	}
+
+	pruned := make([]FuncDecl, 0, len(decls))
+	for _, decl := range decls {
+		switch d := decl.(type) {
+		case *PrivateFuncDecl:
+			if !reached[d] {
+				continue
+			}
+		case *InstanceFuncDecl:
+			if !reached[d] {
+				continue
+			}
+		}
+		pruned = append(pruned, decl)
+	}
+
+	privates := make([]*PrivateFuncDecl, 0, len(r.decls))
+	for _, decl := range r.decls {
+		if reached[decl] {
+			privates = append(privates, decl)
+		}
+	}
+	r.decls = privates
+
+	return pruned
+}
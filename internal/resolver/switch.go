@@ -0,0 +1,209 @@
+package resolver
+
+import (
+	"fmt"
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"github.com/yuemori/blueprinter/internal/parser"
+
+	"github.com/pkg/errors"
+)
+
+var _ constructor = (*switchConstructor)(nil)
+
+// A switchConstructor is a constructor for an interface marked as `provider:switch`.
+// It builds a value with one of the candidate constructors chosen at runtime by the selector,
+// which is a field or a method of the container.
+type switchConstructor struct {
+	iface    *parser.Iface
+	selector string
+	cases    []*switchCase
+	library  string
+}
+
+// A switchCase is a candidate constructor of a switchConstructor.
+type switchCase struct {
+	value string
+	// constant is the constant referred by a qualified value like 'config.ModeProd', or nil.
+	constant types.Object
+	fn       constructor
+	// pos is the position of `provider:case`.
+	pos token.Position
+}
+
+// newSwitchConstructor returns a switchConstructor whose selector is the expression 'selector' of the type 'selectorType'.
+// The values of 'cases' are type-checked against 'selectorType' in the package 'library' of the generated code.
+func newSwitchConstructor(iface *parser.Iface, selector string, selectorType types.Type, library *types.Package, cases []*switchCase) (*switchConstructor, error) {
+	for _, c := range cases {
+		expr, err := goparser.ParseExpr(c.value)
+		if err != nil {
+			return nil, &parser.DirectiveError{Pos: c.pos, Err: errors.Errorf("%s: invalid case value %s", iface.String(), c.value)}
+		}
+		t, err := c.typeOf(expr, selectorType, library)
+		if err != nil {
+			return nil, &parser.DirectiveError{Pos: c.pos, Err: errors.Wrap(err, iface.String())}
+		}
+		if !types.AssignableTo(t, selectorType) {
+			return nil, &parser.DirectiveError{Pos: c.pos, Err: errors.Errorf("%s: case value %s of the type %s cannot be compared with the selector %s of the type %s",
+				iface.String(), c.value, parser.TypeNamePrefixedByImportPath(t), selector, parser.TypeNamePrefixedByImportPath(selectorType))}
+		}
+		if !types.AssignableTo(c.fn.result(), iface.Type()) {
+			return nil, &parser.DirectiveError{Pos: c.pos, Err: errors.Errorf("%s: %s does not return an implementation of the interface", iface.String(), c.fn.String())}
+		}
+	}
+
+	return &switchConstructor{
+		iface:    iface,
+		selector: selector,
+		cases:    cases,
+		library:  library.Path(),
+	}, nil
+}
+
+// typeOf returns the type of the case value 'expr', which is a literal, an identifier declared in the package 'library',
+// or a constant qualified by the name of a package like 'config.ModeProd'.
+// The package is the one declaring the type of the selector, or one imported by 'library'.
+func (c *switchCase) typeOf(expr ast.Expr, selectorType types.Type, library *types.Package) (types.Type, error) {
+	switch e := expr.(type) {
+	case *ast.BasicLit, *ast.Ident:
+		tv, err := types.Eval(token.NewFileSet(), library, token.NoPos, c.value)
+		if err != nil {
+			return nil, errors.Errorf("case value %s is not found in %s", c.value, library.Path())
+		}
+		return tv.Type, nil
+	case *ast.SelectorExpr:
+		x, ok := e.X.(*ast.Ident)
+		if !ok {
+			break
+		}
+		pkg := packageNamed(x.Name, selectorType, library)
+		if pkg == nil {
+			return nil, errors.Errorf("case value %s refers to the unknown package %s", c.value, x.Name)
+		}
+		obj, ok := pkg.Scope().Lookup(e.Sel.Name).(*types.Const)
+		if !ok || !obj.Exported() {
+			return nil, errors.Errorf("case value %s is not an exported constant of %s", c.value, pkg.Path())
+		}
+		c.constant = obj
+		return obj.Type(), nil
+	}
+	return nil, errors.Errorf("case value must be a literal, an identifier or a qualified constant, but: %s", c.value)
+}
+
+// packageNamed returns the package named 'name' declaring 'selectorType' or imported by 'library', or nil.
+func packageNamed(name string, selectorType types.Type, library *types.Package) *types.Package {
+	if named, ok := selectorType.(*types.Named); ok {
+		if pkg := named.Obj().Pkg(); pkg != nil && pkg.Name() == name {
+			return pkg
+		}
+	}
+	for _, pkg := range library.Imports() {
+		if pkg.Name() == name {
+			return pkg
+		}
+	}
+	return nil
+}
+
+// expr returns the case value referred from the package 'library'.
+func (c *switchCase) expr(library string) string {
+	if c.constant != nil {
+		return parser.LocalQualifiedName(c.constant, library)
+	}
+	return c.value
+}
+
+func (s *switchConstructor) Name() string {
+	return s.iface.Name()
+}
+
+func (s *switchConstructor) ImportPath() string {
+	return s.iface.ImportPath()
+}
+
+func (s *switchConstructor) FullPkg() string {
+	return s.iface.FullPkg()
+}
+
+func (s *switchConstructor) FullImportPath() string {
+	return s.iface.FullImportPath()
+}
+
+func (s *switchConstructor) String() string {
+	names := make([]string, 0, len(s.cases))
+	for _, c := range s.cases {
+		names = append(names, c.fn.String())
+	}
+	return fmt.Sprintf("switch %s { %s }", s.selector, strings.Join(names, ", "))
+}
+
+func (s *switchConstructor) MustBeResolved() bool {
+	return false
+}
+
+func (s *switchConstructor) IsStrict() bool {
+	return false
+}
+
+func (s *switchConstructor) IsPrimary() bool {
+	return false
+}
+
+func (s *switchConstructor) IsRoot() bool {
+	return false
+}
+
+func (s *switchConstructor) imports() []string {
+	imports := make([]string, 0, len(s.cases))
+	for _, c := range s.cases {
+		imports = append(imports, c.fn.imports()...)
+		if c.constant != nil {
+			imports = append(imports, parser.ImportPathsOfObject(c.constant, s.library)...)
+		}
+	}
+	return imports
+}
+
+// params returns the params of all candidates in order.
+func (s *switchConstructor) params() []*types.Var {
+	vars := make([]*types.Var, 0)
+	for _, c := range s.cases {
+		vars = append(vars, c.fn.params()...)
+	}
+	return vars
+}
+
+func (s *switchConstructor) optional(i int) bool {
+	for _, c := range s.cases {
+		n := len(c.fn.params())
+		if i < n {
+			return c.fn.optional(i)
+		}
+		i -= n
+	}
+	return false
+}
+
+func (s *switchConstructor) result() types.Type {
+	return s.iface.Type()
+}
+
+// build returns a switch statement which assigns the value built by the chosen candidate
+// to the variable named decorateeName, unlike other constructors which return an expression.
+func (s *switchConstructor) build(library string, args []Derivation) string {
+	body := fmt.Sprintf("\tswitch %s {\n", s.selector)
+	for _, c := range s.cases {
+		n := len(c.fn.params())
+		expr := c.fn.build(library, args[:n])
+		args = args[n:]
+		body += fmt.Sprintf("\tcase %s:\n\t\t%s = %s\n", c.expr(library), decorateeName, strings.ReplaceAll(expr, "\n", "\n\t"))
+	}
+	body += fmt.Sprintf("\tdefault:\n\t\tpanic(%q)\n", "blueprinter: unknown "+s.selector+" for "+s.iface.String())
+	body += "\t}"
+
+	return body
+}
@@ -0,0 +1,107 @@
+package resolver
+
+import (
+	"go/token"
+	"sort"
+)
+
+// An UnusedKind is the kind of an Unused.
+type UnusedKind string
+
+const (
+	// UnusedConstructor is a constructor never called by the container,
+	// because it is unreachable from the roots or it cannot be resolved.
+	UnusedConstructor UnusedKind = "constructor"
+	// UnusedBinding is an interface bound to a constructor, which no param consumes.
+	UnusedBinding UnusedKind = "binding"
+	// UnusedField is a field of the container, which no derivation uses.
+	UnusedField UnusedKind = "field"
+)
+
+// An Unused is a constructor, a binding or a field of the container which is never used.
+type Unused struct {
+	Kind UnusedKind
+	// Name is like 'path/to/package.NewFoo', 'path/to/package.Iface' or 'path/to/package.Container.field'.
+	Name string
+	// Detail describes the item, like the constructor bound to the interface.
+	Detail string
+	// Pos is the position of the declaration, which is invalid if unknown.
+	Pos token.Position
+}
+
+// Unused returns the constructors, the bindings and the fields which are not used by the container,
+// found by ResolveGraph. It is always nil for the graph returned by NewGraph.
+func (g *Graph) Unused() []*Unused {
+	return g.unused
+}
+
+// positioned is a constructor declared in the scanned packages.
+type positioned interface {
+	Position() token.Position
+}
+
+// findUnused returns the items which are resolved by 'r' but not used in 'g' built from 'decls'.
+func (r *Resolver) findUnused(g *Graph, decls []FuncDecl) []*Unused {
+	unused := make([]*Unused, 0)
+
+	for _, c := range r.constructors() {
+		if _, ok := g.Node(c.String()); ok {
+			continue
+		}
+		u := &Unused{Kind: UnusedConstructor, Name: c.String()}
+		if p, ok := c.(positioned); ok {
+			u.Pos = p.Position()
+		}
+		unused = append(unused, u)
+	}
+
+	consumed := make(map[string]bool)
+	usedFields := make(map[*FieldDecl]bool)
+	for _, e := range g.Edges {
+		if e.Iface != nil {
+			consumed[e.Iface.String()] = true
+		}
+		if e.Field != nil {
+			usedFields[e.Field] = true
+		}
+	}
+	for iface, c := range r.bindings {
+		if consumed[iface.String()] {
+			continue
+		}
+		unused = append(unused, &Unused{Kind: UnusedBinding, Name: iface.String(), Detail: "bound to " + c.String(), Pos: iface.Position()})
+	}
+
+	// Fields are also used by the getters and the selectors of switches.
+	for _, getter := range r.getters {
+		usedFields[getter.field] = true
+	}
+	selectors := make(map[string]bool)
+	for _, decl := range decls {
+		if d, ok := decl.(*PrivateFuncDecl); ok {
+			if s, ok := d.fn.(*switchConstructor); ok {
+				selectors[s.selector] = true
+			}
+		}
+	}
+	positions := make(map[string]token.Position)
+	if fields, err := r.provider.Fields(); err == nil {
+		for _, f := range fields {
+			positions[f.Name()] = f.Position()
+		}
+	}
+	for _, f := range r.fields {
+		if usedFields[f] || selectors["f."+f.Name] {
+			continue
+		}
+		unused = append(unused, &Unused{Kind: UnusedField, Name: r.provider.String() + "." + f.Name, Pos: positions[f.Name]})
+	}
+
+	sort.SliceStable(unused, func(x, y int) bool {
+		if unused[x].Kind != unused[y].Kind {
+			return unused[x].Kind < unused[y].Kind
+		}
+		return unused[x].Name < unused[y].Name
+	})
+	return unused
+}
@@ -0,0 +1,208 @@
+package resolver
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yuemori/blueprinter/internal/logger"
+	"github.com/yuemori/blueprinter/internal/parser"
+
+	"github.com/pkg/errors"
+)
+
+// setupWireSets applies the provider sets given by `blueprinter:wire` on the container:
+// the listed functions become bindable, and the structs given by `wire.Struct` are added to the injectables.
+// A struct which is also marked as `provider:inject` is filled as the annotation says.
+// The sets which are not applied are ignored, so that sets like ProdSet and TestSet can bind an interface differently.
+func (r *Resolver) setupWireSets() []error {
+	errs := make([]error, 0)
+	seen := make(map[string]bool)
+	for _, c := range r.injectables {
+		seen[c.String()] = true
+	}
+
+	sets := make(map[string]*parser.WireSet)
+	for _, set := range r.cache.WireSets() {
+		sets[set.Name()] = set
+	}
+
+	r.wired = make(map[string]bool)
+	external := make([]*parser.Object, 0)
+	for _, name := range r.provider.WireSets() {
+		set, ok := sets[name]
+		if !ok {
+			errs = append(errs, errors.Errorf("the provider set %s given by `%s` on %s is not declared in the scanned packages", name, parser.DirectiveWire, r.provider.String()))
+			continue
+		}
+		r.wireSets = append(r.wireSets, set)
+
+		for _, msg := range set.Unsupported() {
+			logger.Debug("Skip(unsupported by blueprinter):", set.Name(), msg)
+		}
+		for _, fn := range set.Funcs() {
+			if fn.Results().Len() != 1 {
+				logger.Debug("Skip(returning an error or a cleanup function):", set.Name(), fn.String())
+				continue
+			}
+			if r.wired[fn.String()] {
+				continue
+			}
+			r.wired[fn.String()] = true
+			// Functions declared outside the scanned packages are used only by the containers applying the set.
+			if _, ok := r.cache.Get(fn.ImportPath(), fn.Name()); !ok && r.cache.Dir(fn.ImportPath()) == "" {
+				external = append(external, fn.Object)
+			}
+		}
+
+		for _, st := range set.Structs() {
+			if seen[st.String()] {
+				continue
+			}
+			seen[st.String()] = true
+
+			fields, err := st.InjectedFields()
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			r.injectables = append(r.injectables, &structConstructor{Struct: st.Struct, fields: fields})
+		}
+	}
+	if len(external) > 0 {
+		r.cache = r.cache.WithObjects(external)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// isBindable returns true if 'fn' can be bound, including the functions without params listed in the applied provider sets.
+func (r *Resolver) isBindable(fn *parser.Func) bool {
+	return fn.IsBindable() || (r.wired[fn.String()] && !fn.IsGeneric() && fn.Results().Len() == 1)
+}
+
+// wireHint returns the reason why no derivation of 't' is found in the applied provider sets, or an empty string.
+func (r *Resolver) wireHint(t parser.Type) string {
+	for _, set := range r.wireSets {
+		for _, fn := range set.Unexported() {
+			if fn.Results().Len() == 1 && parser.Identical(fn.Results().At(0).Type(), t) {
+				return fmt.Sprintf("%s in %s is unexported and cannot be called from the container", fn.String(), set.Name())
+			}
+		}
+	}
+	for _, set := range r.wireSets {
+		if names := set.Unscanned(); len(names) > 0 {
+			return fmt.Sprintf("the provider sets %s included by %s are not declared in the scanned packages, add their packages with --include-packages",
+				strings.Join(names, ", "), set.Name())
+		}
+	}
+	return ""
+}
+
+// setupWireBindings builds bindings for the interfaces given by `wire.Bind`.
+// It must be called after setupMarkerBindings and before setupBindings, since these bindings take precedence over the ones found from the implementations.
+func (r *Resolver) setupWireBindings() []error {
+	errs := make([]error, 0)
+	bound := make(map[string]constructor)
+
+	for _, set := range r.wireSets {
+		for _, b := range set.Bindings() {
+			iface := b.Iface()
+			if iface.IsResolve() || iface.IsSwitch() {
+				errs = append(errs, &parser.ProviderError{Pos: b.Pos(), Err: errors.Errorf("%s is bound by wire.Bind, and cannot have `provider:resolve` or `provider:switch`", iface.String())})
+				continue
+			}
+
+			fn, err := r.wireConstructor(set, b)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if prev, ok := bound[iface.String()]; ok {
+				if prev.String() != fn.String() {
+					errs = append(errs, &parser.ProviderError{Pos: b.Pos(), Err: errors.Errorf("%s is bound to both %s and %s", iface.String(), prev.String(), fn.String())})
+				}
+				continue
+			}
+			bound[iface.String()] = fn
+			if prev, ok := r.explicitlyBound(iface); ok {
+				errs = append(errs, &parser.ProviderError{Pos: b.Pos(), Err: errors.Errorf("%s is bound to both %s by blueprint.Bind and %s by wire.Bind", iface.String(), prev.String(), fn.String())})
+				continue
+			}
+			r.bindings[iface] = fn
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// wireConstructor returns the constructor for the implementation of 'b'.
+// The providers in 'set' are preferred, and then the constructors in the scanned packages.
+func (r *Resolver) wireConstructor(set *parser.WireSet, b *parser.WireBinding) (constructor, error) {
+	fns := make([]constructor, 0)
+	seen := make(map[string]bool)
+	for _, fn := range set.Funcs() {
+		if !r.isBindable(fn) || seen[fn.String()] || !parser.Identical(fn.Results().At(0).Type(), b.Impl()) {
+			continue
+		}
+		seen[fn.String()] = true
+		fns = append(fns, &funcConstructor{fn})
+	}
+	for _, st := range set.Structs() {
+		c, ok := r.findInjectable(st.ImportPath(), st.Name())
+		if !ok || seen[c.String()] || !parser.Identical(c.result(), b.Impl()) {
+			continue
+		}
+		seen[c.String()] = true
+		fns = append(fns, c)
+	}
+	if len(fns) == 0 {
+		fns = r.constructorsFor(b.Impl())
+	}
+
+	// If multiple functions are found, prefer the ones marked as 'provider:primary'.
+	if len(fns) > 1 {
+		primaries := make([]constructor, 0)
+		for _, fn := range fns {
+			if fn.IsPrimary() {
+				primaries = append(primaries, fn)
+			}
+		}
+		if len(primaries) > 0 {
+			fns = primaries
+		}
+	}
+	if len(fns) > 1 {
+		names := make([]string, 0, len(fns))
+		candidates := make([]*Candidate, 0, len(fns))
+		for _, fn := range fns {
+			names = append(names, fn.String())
+			candidates = append(candidates, &Candidate{Name: fn.String(), Pos: positionOf(fn)})
+		}
+		return nil, &AmbiguousError{
+			Iface:      b.Iface().String(),
+			Pos:        b.Pos(),
+			Candidates: candidates,
+			msg: fmt.Sprintf("%s: unable to determine a constructor of %s for %s: [%s]",
+				b.Pos(), parser.QualifiedTypeName(b.Impl()), b.Iface().String(), strings.Join(names, ", ")),
+		}
+	}
+	if len(fns) == 0 {
+		reason := "no constructor is found"
+		if hint := r.wireHint(b.Impl()); hint != "" {
+			reason = hint
+		}
+		return nil, &parser.ProviderError{Pos: b.Pos(), Err: errors.Errorf("unable to determine a constructor of %s for %s: %s",
+			parser.QualifiedTypeName(b.Impl()), b.Iface().String(), reason)}
+	}
+	if !parser.AssignableTo(fns[0].result(), b.Iface().Type()) {
+		return nil, &parser.ProviderError{Pos: b.Pos(), Err: errors.Errorf("%s does not implement %s", fns[0].String(), b.Iface().String())}
+	}
+
+	return fns[0], nil
+}
@@ -0,0 +1,32 @@
+package runner
+
+import (
+	"fmt"
+
+	"github.com/yuemori/blueprinter/internal/parser"
+	"github.com/yuemori/blueprinter/internal/resolver"
+)
+
+// Lint resolves the dependencies of 'containers' with 'cache' returned by Parse, and returns the errors of
+// the containers which cannot be resolved or whose dependencies violate the rules of their Architecture.
+// Nothing is generated.
+func Lint(cache *parser.ObjectCache, containers []*parser.Container, profile string) []error {
+	errs := make([]error, 0)
+	for _, c := range containers {
+		scoped := cache.InScope(c).WithProfile(profile)
+		graph, err, resolveErrs := resolver.ResolveGraph(scoped, c.Name, c.Package)
+		if err != nil {
+			resolveErrs = []error{err}
+		}
+		if resolveErrs == nil {
+			resolveErrs = resolver.CheckArchitecture(graph, scoped, c.Architecture)
+		}
+		for _, err := range resolveErrs {
+			errs = append(errs, fmt.Errorf("%s.%s: %w", c.Package, c.Name, err))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
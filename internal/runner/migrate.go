@@ -0,0 +1,226 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/format"
+	"go/types"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/yuemori/blueprinter/internal/parser"
+)
+
+var migrateTemplate = `package {{ .Package }}
+{{ if .Imports }}
+import ({{ range .Imports }}
+	{{ . }}
+{{- end }}
+)
+{{ end }}
+// {{ .ContainerName }} is migrated from the wire injector {{ .Injector }}.
+// The params of the injector are given as the fields.
+{{- if .WireSets }}
+// The provider sets are applied until they are replaced by the annotations.
+//
+// blueprinter:wire {{ join .WireSets " " }}
+{{- end }}
+type {{ .ContainerName }} struct {
+{{- range .Fields }}
+	{{ .Name }} {{ .Type }}
+{{- end }}
+}
+`
+
+// A MigrateConfig is a configuration for Migrate.
+type MigrateConfig struct {
+	// Dest is where the container struct is written.
+	Dest io.Writer
+	// Notes is where the annotations required to replace the provider sets are written.
+	Notes         io.Writer
+	WorkDir       string
+	Globs         []string
+	Ignores       []string
+	Includes      []string
+	Package       string
+	Injector      string
+	ContainerName string
+	// Namespace is the prefixes of the provider directives, which are used in the notes as well.
+	Namespace parser.Namespace
+}
+
+type migrateData struct {
+	Package       string
+	Imports       []string
+	Injector      string
+	ContainerName string
+	// WireSets are the provider sets given to `wire.Build`, which are applied by `blueprinter:wire`.
+	WireSets []string
+	Fields   []*migrateField
+}
+
+type migrateField struct {
+	Name string
+	Type string
+}
+
+// Migrate converts the wire injector into a container struct, and writes the annotations
+// which replace the provider sets given to `wire.Build`.
+func Migrate(cfg *MigrateConfig) []error {
+	ctx := context.Background()
+
+	cache, errs := parser.Parse(ctx, &parser.Config{
+		Dir:      cfg.WorkDir,
+		Env:      os.Environ(),
+		Globs:    cfg.Globs,
+		Ignores:  cfg.Ignores,
+		Includes: cfg.Includes,
+		// Injectors are declared in files guarded by the 'wireinject' build tag.
+		BuildTags: []string{"wireinject"},
+		Namespace: cfg.Namespace,
+	})
+	if errs != nil {
+		return errs
+	}
+
+	var injector *parser.WireInjector
+	for _, i := range cache.WireInjectors() {
+		if i.Pkg().Path() == cfg.Package && i.Name() == cfg.Injector {
+			injector = i
+			break
+		}
+	}
+	if injector == nil {
+		return []error{fmt.Errorf("injector %s.%s is not found", cfg.Package, cfg.Injector)}
+	}
+
+	data := &migrateData{
+		Package:       injector.Pkg().Name(),
+		Imports:       make([]string, 0),
+		Injector:      injector.Name(),
+		ContainerName: cfg.ContainerName,
+		WireSets:      injector.Set().Includes(),
+		Fields:        make([]*migrateField, 0),
+	}
+	imports := make(map[string]bool)
+	params := injector.Signature().Params()
+	for i := 0; i < params.Len(); i++ {
+		param := params.At(i)
+		name := param.Name()
+		if name == "" || name == "_" {
+			name = fmt.Sprintf("p%d", i)
+		}
+		data.Fields = append(data.Fields, &migrateField{
+			Name: name,
+			Type: parser.LocalQualifiedTypeName(param.Type(), cfg.Package),
+		})
+		for _, imp := range parser.ImportPathsOf(param.Type(), cfg.Package) {
+			imports[imp] = true
+		}
+	}
+	for imp := range imports {
+		data.Imports = append(data.Imports, imp)
+	}
+	sort.Strings(data.Imports)
+
+	t, err := template.New("migrate").Funcs(template.FuncMap{"join": strings.Join}).Parse(migrateTemplate)
+	if err != nil {
+		return []error{err}
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return []error{err}
+	}
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return []error{err}
+	}
+	if _, err := cfg.Dest.Write(src); err != nil {
+		return []error{err}
+	}
+
+	for _, note := range migrateNotes(cache, injector, cfg.Namespace) {
+		fmt.Fprintln(cfg.Notes, note)
+	}
+
+	return nil
+}
+
+// migrateNotes returns the annotations required to resolve the providers of 'injector' without google/wire.
+func migrateNotes(cache *parser.ObjectCache, injector *parser.WireInjector, ns parser.Namespace) []string {
+	set := injector.Set()
+	notes := make([]string, 0)
+	seen := make(map[string]bool)
+	add := func(note string) {
+		if !seen[note] {
+			seen[note] = true
+			notes = append(notes, note)
+		}
+	}
+
+	results := injector.Signature().Results()
+	if results.Len() > 0 {
+		add(fmt.Sprintf("%s: call the Resolve method of the container for %s instead of %s",
+			injector.Pos(), parser.TypeNamePrefixedByImportPath(results.At(0).Type()), injector.Name()))
+	}
+
+	for _, fn := range set.Funcs() {
+		if fn.Results().Len() != 1 {
+			add(fmt.Sprintf("%s: %s returns an error or a cleanup function, which is not supported", set.Pos(), fn.String()))
+			continue
+		}
+		if fn.Params().Len() == 0 {
+			add(fmt.Sprintf("%s: add `// %s` to %s", set.Pos(), ns.Format(parser.DirectiveInclude), fn.String()))
+		}
+	}
+
+	for _, st := range set.Structs() {
+		add(fmt.Sprintf("%s: add `// %s` to %s", st.Pos(), ns.Format(parser.DirectiveInject), st.String()))
+		if names := st.FieldNames(); names != nil {
+			add(fmt.Sprintf("%s: tag the fields %s of %s with `blueprinter:\"inject\"`", st.Pos(), strings.Join(names, ", "), st.String()))
+		}
+	}
+
+	for _, b := range set.Bindings() {
+		provider := wireProviderOf(set, b.Impl())
+		if _, ok := cache.Get(b.Iface().ImportPath(), b.Iface().Name()); ok {
+			if provider == nil {
+				add(fmt.Sprintf("%s: add `// %s` to %s, which is bound to %s",
+					b.Pos(), ns.Format(parser.DirectivePrimary), parser.TypeNamePrefixedByImportPath(b.Impl()), b.Iface().String()))
+				continue
+			}
+			add(fmt.Sprintf("%s: add `// %s %s %s` to %s", b.Pos(), ns.Format(parser.DirectiveResolve), provider.ImportPath(), provider.Name(), b.Iface().String()))
+			continue
+		}
+		target := parser.TypeNamePrefixedByImportPath(b.Impl())
+		if provider != nil {
+			target = provider.String()
+		}
+		add(fmt.Sprintf("%s: add `// %s %s` to %s", b.Pos(), ns.Format(parser.DirectiveBind), b.Iface().String(), target))
+	}
+
+	for _, msg := range set.Unsupported() {
+		add(msg)
+	}
+
+	return notes
+}
+
+// wireProviderOf returns the provider of 't' in 'set', or nil.
+func wireProviderOf(set *parser.WireSet, t parser.Type) *parser.Object {
+	for _, fn := range set.Funcs() {
+		if fn.Results().Len() > 0 && parser.Identical(fn.Results().At(0).Type(), t) {
+			return fn.Object
+		}
+	}
+	for _, st := range set.Structs() {
+		if parser.Identical(st.Object.Type(), t) || parser.Identical(types.NewPointer(st.Object.Type()), t) {
+			return st.Object
+		}
+	}
+	return nil
+}
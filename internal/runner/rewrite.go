@@ -0,0 +1,49 @@
+package runner
+
+import (
+	"os"
+
+	"github.com/yuemori/blueprinter/internal/parser"
+)
+
+// A RewriteConfig is a configuration for Rewrite.
+type RewriteConfig struct {
+	WorkDir string
+	Globs   []string
+	Ignores []string
+	// Prefix is the prefix which the provider directives are rewritten into, like 'blueprinter:'.
+	Prefix string
+	// DryRun reports the files to be rewritten without writing them.
+	DryRun bool
+}
+
+// Rewrite rewrites the provider directives written with parser.LegacyPrefix under cfg.WorkDir into cfg.Prefix,
+// and returns the rewritten files.
+func Rewrite(cfg *RewriteConfig) ([]*parser.Rewrite, []error) {
+	rewrites, errs := parser.RewriteDirectives(&parser.Config{
+		Dir:       cfg.WorkDir,
+		Globs:     cfg.Globs,
+		Ignores:   cfg.Ignores,
+		Namespace: parser.Namespace{Prefix: cfg.Prefix},
+	})
+	if errs != nil {
+		return nil, errs
+	}
+	if cfg.DryRun {
+		return rewrites, nil
+	}
+
+	for _, r := range rewrites {
+		if r.Count == 0 {
+			continue
+		}
+		info, err := os.Stat(r.Path)
+		if err != nil {
+			return nil, []error{err}
+		}
+		if err := os.WriteFile(r.Path, r.Src, info.Mode()); err != nil {
+			return nil, []error{err}
+		}
+	}
+	return rewrites, nil
+}
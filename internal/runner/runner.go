@@ -2,15 +2,17 @@ package runner
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"os"
+	"sync"
 	"text/template"
 
 	"github.com/yuemori/blueprinter/internal/parser"
 	"github.com/yuemori/blueprinter/internal/resolver"
 )
 
-var DefaultTemplate = `//go:build !skip_blueprinter
+var DefaultTemplate = `//go:build !skip_blueprinter{{ range .BuildTags }} && {{ . }}{{ end }}
 
 // Code generated by blueprinter. DO NOT EDIT.
 
@@ -38,40 +40,193 @@ func ({{.Receiver}}) {{.FuncName}}() {{.FuncReturn}} {
 }
 {{ end}}
 {{- end}}
+
+{{- range .TypeDecls}}
+// {{ .Pkg }}
+{{.TypeImpl}}
+{{- end}}
 `
 
 type Config struct {
-	Template         string
-	Dest             io.Writer
-	WorkDir          string
-	Globs            []string
-	Ignores          []string
+	Template string
+	Dest     io.Writer
+	WorkDir  string
+	Globs    []string
+	Ignores  []string
+	// Includes are import path patterns of packages to be scanned in addition to WorkDir, like 'github.com/owner/repo/...'.
+	Includes []string
+	// Scans and Excludes are given by the directives of the container struct.
+	Scans    []parser.DirPattern
+	Excludes []parser.DirPattern
+	// Workspace enables scanning all modules listed in go.work instead of WorkDir only.
+	Workspace bool
+	// Dirs are the directories scanned in addition to WorkDir or the workspace.
+	Dirs             []string
 	ContainerName    string
 	ContainerPackage string
+	// Profile selects the objects marked as `provider:profile`. If empty, only objects without it are used.
+	Profile string
+	// BuildTags are added to the build constraint of the generated code.
+	BuildTags []string
+	// Namespace is the prefixes of the provider directives.
+	Namespace parser.Namespace
+	// Architecture is the layer rules given by the directives of the container struct, or nil.
+	Architecture *parser.Architecture
+	// Strict are the patterns of the packages whose exported constructors must be resolved,
+	// like 'github.com/owner/repo/...' or './internal/...' relative to WorkDir.
+	Strict []string
 }
 
-func Run(cfg *Config) []error {
-	ctx := context.Background()
+// LoadContainer reads the directives of the container struct in the package 'pkg'.
+// If 'name' is empty, the struct having the directives is looked up.
+func LoadContainer(workDir, pkg, name string, ns parser.Namespace) (*parser.Container, []error) {
+	return parser.LoadContainer(context.Background(), workDir, os.Environ(), pkg, name, ns)
+}
 
-	cache, errs := parser.Parse(ctx, cfg.WorkDir, os.Environ(), cfg.Globs, cfg.Ignores)
+func Run(cfg *Config) []error {
+	cache, errs := Parse(cfg)
 	if errs != nil {
 		return errs
 	}
 
-	data, err, errs := resolver.Resolve(cache, cfg.ContainerName, cfg.ContainerPackage)
+	t, err := template.New("provider").Parse(cfg.Template)
 	if err != nil {
 		return []error{err}
 	}
+
+	return render(t, cache.WithProfile(cfg.Profile), cfg.ContainerName, cfg.ContainerPackage, cfg.Architecture, cfg.BuildTags, cfg.Dest)
+}
+
+// Parse loads the packages given by 'cfg' into an ObjectCache, which can be shared by multiple containers.
+func Parse(cfg *Config) (*parser.ObjectCache, []error) {
+	ctx := context.Background()
+
+	parserCfg := &parser.Config{
+		Dir:       cfg.WorkDir,
+		Env:       os.Environ(),
+		Globs:     cfg.Globs,
+		Ignores:   cfg.Ignores,
+		Includes:  cfg.Includes,
+		Scans:     cfg.Scans,
+		Excludes:  cfg.Excludes,
+		Namespace: cfg.Namespace,
+		Strict:    cfg.Strict,
+	}
+	// The container package is always loaded even if it is out of Scans.
+	if len(cfg.Scans) > 0 && cfg.ContainerPackage != "" {
+		parserCfg.Includes = append(parserCfg.Includes, cfg.ContainerPackage)
+	}
+	if cfg.Workspace {
+		roots, err := parser.WorkspaceRoots(cfg.WorkDir, parserCfg.Env)
+		if err != nil {
+			return nil, []error{err}
+		}
+		parserCfg.Roots = roots
+	}
+	if len(cfg.Dirs) > 0 {
+		if len(parserCfg.Roots) == 0 {
+			parserCfg.Roots = []string{cfg.WorkDir}
+		}
+		parserCfg.Roots = append(parserCfg.Roots, cfg.Dirs...)
+	}
+
+	return parser.Parse(ctx, parserCfg)
+}
+
+// ParseContainers loads the packages given by 'cfg' and returns the structs marked as `blueprinter:container` in them.
+// The packages are loaded again with the import paths and the directories given by 'scan' of the containers
+// if they are not loaded, since they are unknown until the containers are found.
+// Loading again costs as much as the first load, which is avoided by giving the scanned packages with Includes or Dirs.
+func ParseContainers(cfg *Config) (*parser.ObjectCache, []*parser.Container, []error) {
+	cache, errs := Parse(cfg)
 	if errs != nil {
-		return errs
+		return nil, nil, errs
+	}
+	containers, err := cache.Containers()
+	if err != nil {
+		return nil, nil, []error{err}
 	}
 
-	t, err := template.New("provider").Parse(cfg.Template)
+	includes := make([]string, 0)
+	dirs := make([]string, 0)
+	for _, c := range containers {
+		i, d := cache.Uncovered(c)
+		includes = append(includes, i...)
+		dirs = append(dirs, d...)
+	}
+	if len(includes) == 0 && len(dirs) == 0 {
+		return cache, containers, nil
+	}
+
+	extended := *cfg
+	extended.Includes = append(append([]string{}, cfg.Includes...), includes...)
+	extended.Dirs = append(append([]string{}, cfg.Dirs...), dirs...)
+	cache, errs = Parse(&extended)
+	if errs != nil {
+		return nil, nil, errs
+	}
+	containers, err = cache.Containers()
+	if err != nil {
+		return nil, nil, []error{err}
+	}
+	return cache, containers, nil
+}
+
+// A Target is a container generated by Generate.
+type Target struct {
+	Dest      io.Writer
+	Container *parser.Container
+	// Profile and BuildTags are the same as the ones of Config.
+	Profile   string
+	BuildTags []string
+}
+
+// Generate generates the containers of 'targets' in parallel, sharing 'cache' returned by Parse.
+// Each container uses only the objects in the scope given by its directives.
+// The cache is only read after Parse, and each resolver instantiates generic functions in its own types.Context.
+// The dests of 'targets' are written concurrently, so they must be distinct writers.
+func Generate(cache *parser.ObjectCache, tmpl string, targets []*Target) []error {
+	t, err := template.New("provider").Parse(tmpl)
 	if err != nil {
 		return []error{err}
 	}
 
-	if err := t.Execute(cfg.Dest, data); err != nil {
+	results := make([][]error, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target *Target) {
+			defer wg.Done()
+			c := target.Container
+			results[i] = render(t, cache.InScope(c).WithProfile(target.Profile), c.Name, c.Package, c.Architecture, target.BuildTags, target.Dest)
+		}(i, target)
+	}
+	wg.Wait()
+
+	errs := make([]error, 0)
+	for i, result := range results {
+		for _, err := range result {
+			errs = append(errs, fmt.Errorf("%s.%s: %w", targets[i].Container.Package, targets[i].Container.Name, err))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// render resolves the container 'name' in the package 'pkg' checked by 'arch', and writes the generated code to 'dest'.
+func render(t *template.Template, cache *parser.ObjectCache, name, pkg string, arch *parser.Architecture, buildTags []string, dest io.Writer) []error {
+	data, err, errs := resolver.Resolve(cache, name, pkg, arch)
+	if err != nil {
+		return []error{err}
+	}
+	if errs != nil {
+		return errs
+	}
+	data.BuildTags = buildTags
+
+	if err := t.Execute(dest, data); err != nil {
 		return []error{err}
 	}
 
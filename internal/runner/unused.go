@@ -0,0 +1,82 @@
+package runner
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/yuemori/blueprinter/internal/parser"
+	"github.com/yuemori/blueprinter/internal/resolver"
+)
+
+// An Unused is an item not used by the containers.
+type Unused struct {
+	*resolver.Unused
+	// Containers are the containers like 'path/to/package.Container' which do not use the item.
+	Containers []string
+}
+
+// FindUnused resolves the dependencies of 'containers' with 'cache' returned by Parse, and returns
+// the constructors, the bindings and the fields which they do not use.
+// A constructor called by any of the containers and an interface consumed by any of them are not reported,
+// while the fields are reported for each container.
+func FindUnused(cache *parser.ObjectCache, containers []*parser.Container, profile string) ([]*Unused, []error) {
+	errs := make([]error, 0)
+	items := make(map[string]*Unused)
+	keys := make([]string, 0)
+	called := make(map[string]bool)
+	consumed := make(map[string]bool)
+	for _, c := range containers {
+		name := c.Package + "." + c.Name
+		graph, err, resolveErrs := resolver.ResolveGraph(cache.InScope(c).WithProfile(profile), c.Name, c.Package)
+		if err != nil {
+			resolveErrs = []error{err}
+		}
+		if resolveErrs != nil {
+			for _, err := range resolveErrs {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			}
+			continue
+		}
+
+		for _, n := range graph.Nodes {
+			called[n.String()] = true
+		}
+		for _, e := range graph.Edges {
+			if e.Iface != nil {
+				consumed[e.Iface.String()] = true
+			}
+		}
+		for _, u := range graph.Unused() {
+			key := string(u.Kind) + " " + u.Name
+			item, ok := items[key]
+			if !ok {
+				item = &Unused{Unused: u}
+				items[key] = item
+				keys = append(keys, key)
+			}
+			item.Containers = append(item.Containers, name)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	unused := make([]*Unused, 0, len(keys))
+	for _, key := range keys {
+		item := items[key]
+		if item.Kind == resolver.UnusedConstructor && called[item.Name] {
+			continue
+		}
+		if item.Kind == resolver.UnusedBinding && consumed[item.Name] {
+			continue
+		}
+		unused = append(unused, item)
+	}
+	sort.SliceStable(unused, func(x, y int) bool {
+		if unused[x].Kind != unused[y].Kind {
+			return unused[x].Kind < unused[y].Kind
+		}
+		return unused[x].Name < unused[y].Name
+	})
+	return unused, nil
+}